@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/bgunnarsson/binsql/internal/app"
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
+	"github.com/bgunnarsson/binsql/internal/config"
+)
+
+// command is a binsql subcommand. setup registers its flags and returns
+// the function that runs it with the positional arguments.
+type command struct {
+	name    string
+	args    string // synopsis after "[flags]"
+	summary string
+	setup   func(fs *flag.FlagSet) func(ctx context.Context, args []string) error
+	subs    []*command // "conn list", "conn add", …
+}
+
+// errDiffers is returned by "diff --exit-code" when the schemas differ.
+// It exits with exitError without a message, like diff(1).
+var errDiffers = errors.New("schemas differ")
+
+// commands is filled in init since completion and help refer back to it.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "tui", args: "[<db>]", summary: "Open the interactive TUI", setup: tuiCommand},
+		{name: "query", args: "[<db>] [<sql> | -]", summary: "Run one query and print its result", setup: queryCommand},
+		{name: "exec", args: "-f <file> [<db>]", summary: "Run a script of ;-separated statements", setup: execCommand},
+		{name: "schema", args: "[<db>] [<table>]", summary: "List tables, or the columns of a table", setup: schemaCommand},
+		{name: "export", args: "[<db>] [<sql>]", summary: "Write a query result or table to a file", setup: exportCommand},
+		{name: "diff", args: "<db> <db>", summary: "Compare the tables and columns of two databases", setup: diffCommand},
+		{name: "run", args: "<saved-query> [<db>]", summary: "Run a query from the saved query library", setup: runSavedCommand},
+		{name: "conn", summary: "Manage saved connections", subs: []*command{
+			{name: "list", summary: "List saved connections", setup: connListCommand},
+			{name: "add", args: "<name> [<driver>] <dsn>", summary: "Save a connection (replacing one of the same name)", setup: connAddCommand},
+			{name: "password", args: "<name>", summary: "Ask for a connection's password and store it encrypted", setup: connPasswordCommand},
+			{name: "test", args: "[<db>]", summary: "Connect and report how long it took", setup: connTestCommand},
+			{name: "show", args: "[<db>]", summary: "Print the resolved connection, password redacted", setup: connShowCommand},
+		}},
+		{name: "completion", args: "bash|zsh|fish", summary: "Print a shell completion script", setup: completionCommand},
+	}
+}
+
+func findCommand(cmds []*command, name string) *command {
+	for _, c := range cmds {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// withTimeout applies --timeout when it is set.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// noArgs rejects leftover positional arguments.
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected argument %q", args[0])
+	}
+	return nil
+}
+
+// readSQL reads a query or script from a file, "-" meaning stdin.
+func readSQL(path string) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	return string(b), err
+}
+
+func tuiCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var c connFlags
+	c.register(fs)
+	fs.StringVar(&cellfmt.NullGlyph, "null", cellfmt.NullGlyph, "`text` shown for SQL NULL in the grid")
+	return func(ctx context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		return app.RunInteractive(ctx, conn)
+	}
+}
+
+func queryCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		opts    app.QueryOptions
+		timeout time.Duration
+	)
+	c.register(fs)
+	outputFlags(fs, &opts, "table")
+	echoFlag(fs, &opts)
+	paramFlag(fs, &opts)
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		// No SQL: read it from a pipe, else list the tables.
+		sql := strings.Join(rest, " ")
+		if sql == "-" || (sql == "" && !term.IsTerminal(int(os.Stdin.Fd()))) {
+			if sql, err = readSQL("-"); err != nil {
+				return err
+			}
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.RunNonInteractive(ctx, conn, strings.TrimSpace(sql), opts)
+	}
+}
+
+func execCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		opts    app.QueryOptions
+		file    string
+		timeout time.Duration
+	)
+	c.register(fs)
+	fs.StringVar(&file, "file", "", "SQL script `file` to run; - for stdin")
+	alias(fs, "f", "file")
+	outputFlags(fs, &opts, "table")
+	echoFlag(fs, &opts)
+	paramFlag(fs, &opts)
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		if file == "" {
+			return usagef("missing --file")
+		}
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		script, err := readSQL(file)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.Exec(ctx, conn, script, opts)
+	}
+}
+
+func schemaCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		opts    app.QueryOptions
+		timeout time.Duration
+	)
+	c.register(fs)
+	outputFlags(fs, &opts, "table")
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		table := ""
+		if len(rest) > 0 {
+			table, rest = rest[0], rest[1:]
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.Schema(ctx, conn, table, opts)
+	}
+}
+
+func exportCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		opts    app.QueryOptions
+		table   string
+		out     string
+		timeout time.Duration
+	)
+	c.register(fs)
+	fs.StringVar(&table, "table", "", "export the whole `table` instead of a query")
+	fs.StringVar(&out, "out", "-", "output `file`; - for stdout")
+	alias(fs, "o", "out")
+	fs.StringVar(&opts.Format, "format", "", "output `format`: table, tsv, csv or json (default: by --out extension)")
+	paramFlag(fs, &opts)
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		sql := strings.Join(rest, " ")
+		switch {
+		case table != "" && sql != "":
+			return usagef("give either --table or a query, not both")
+		case table != "":
+			if sql, err = app.TableQuery(conn, table); err != nil {
+				return err
+			}
+		case sql == "-":
+			if sql, err = readSQL("-"); err != nil {
+				return err
+			}
+		case sql == "":
+			return usagef("missing query or --table")
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.Export(ctx, conn, strings.TrimSpace(sql), out, opts)
+	}
+}
+
+func diffCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		from     connFlags
+		to       = connFlags{prefix: "to-"}
+		exitCode bool
+		timeout  time.Duration
+	)
+	from.register(fs)
+	to.timeouts = from.timeouts
+	to.register(fs)
+	fs.BoolVar(&exitCode, "exit-code", false, "exit with 1 when the schemas differ")
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		fromConn, rest, err := from.resolve(args)
+		if err != nil {
+			return err
+		}
+		toConn, rest, err := to.resolve(rest)
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		differ, err := app.DiffSchemas(ctx, fromConn, toConn, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if differ && exitCode {
+			return errDiffers
+		}
+		return nil
+	}
+}
+
+func runSavedCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		opts    app.QueryOptions
+		list    bool
+		timeout time.Duration
+	)
+	c.register(fs)
+	fs.BoolVar(&list, "list", false, "list the saved queries")
+	outputFlags(fs, &opts, "table")
+	echoFlag(fs, &opts)
+	paramFlag(fs, &opts)
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		if list || len(args) == 0 {
+			return app.ListSaved(os.Stdout)
+		}
+		name := args[0]
+		conn, rest, err := c.resolve(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		params := opts.Params
+		opts.Params = nil
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.RunSaved(ctx, conn, name, params, opts)
+	}
+}
+
+func connListCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var names bool
+	fs.BoolVar(&names, "names", false, "print only the names")
+	return func(_ context.Context, args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		return app.ListConnections(os.Stdout, names)
+	}
+}
+
+func connAddCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c         connFlags
+		storePass bool
+		ssh       config.SSH
+		tls       config.TLS
+		timeouts  connTimeouts
+		pool      config.Pool
+		lifetime  time.Duration
+		idleTime  time.Duration
+	)
+	fs.StringVar(&c.driver, "driver", "", "`driver`: sqlite, postgres, mssql or mysql")
+	fs.StringVar(&c.dsn, "dsn", "", "database path or `dsn`")
+	fs.BoolVar(&storePass, "store-password", false, "ask for the password and keep it in the encrypted credentials file")
+	fs.StringVar(&ssh.Host, "ssh", "", "reach the database through SSH jump `host`: [user@]host[:port]")
+	fs.StringVar(&ssh.KeyFile, "ssh-key", "", "private key `file` for --ssh (default: ssh-agent and ~/.ssh/id_*)")
+	fs.BoolVar(&ssh.Agent, "ssh-agent", false, "log in to --ssh with ssh-agent")
+	fs.StringVar(&ssh.KnownHosts, "ssh-known-hosts", "", "known_hosts `file` to verify --ssh against (default ~/.ssh/known_hosts)")
+	fs.StringVar(&tls.Mode, "tls-mode", "", "TLS `mode`: disable, prefer, require, verify-ca or verify-full (the default once any --tls flag is given)")
+	fs.StringVar(&tls.CAFile, "tls-ca", "", "CA certificates `file` (PEM) to verify the server with (default: the system roots)")
+	fs.StringVar(&tls.CertFile, "tls-cert", "", "client certificate `file` (PEM)")
+	fs.StringVar(&tls.KeyFile, "tls-key", "", "client key `file` (PEM)")
+	fs.StringVar(&tls.ServerName, "tls-server-name", "", "`name` the server certificate must have (default: the host)")
+	fs.BoolVar(&tls.SkipVerify, "tls-skip-verify", false, "encrypt but accept any server certificate (insecure)")
+	timeouts.register(fs)
+	fs.IntVar(&pool.MaxOpenConns, "max-open-conns", 0, "open at most `n` connections (default 4)")
+	fs.IntVar(&pool.MaxIdleConns, "max-idle-conns", 0, "keep at most `n` idle connections (default: --max-open-conns)")
+	fs.DurationVar(&lifetime, "conn-max-lifetime", 0, "replace connections after `duration` (default 5m)")
+	fs.DurationVar(&idleTime, "conn-max-idle-time", 0, "close connections idle for `duration` (default never)")
+	return func(_ context.Context, args []string) error {
+		if len(args) == 0 {
+			return usagef("missing connection name")
+		}
+		name := args[0]
+		if _, err := app.ParseDriver(name); err == nil {
+			return usagef("%q is a driver name; pick another connection name", name)
+		}
+		conn, rest, err := c.resolve(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		if ssh.Host != "" {
+			if user, host, ok := strings.Cut(ssh.Host, "@"); ok {
+				ssh.User, ssh.Host = user, host
+			}
+			conn.SSH = &ssh
+		} else if ssh.KeyFile != "" || ssh.Agent || ssh.KnownHosts != "" {
+			return usagef("--ssh-key, --ssh-agent and --ssh-known-hosts need --ssh")
+		}
+		if tls != (config.TLS{}) {
+			conn.TLS = &tls
+		}
+		if err := timeouts.apply(&conn); err != nil {
+			return err
+		}
+		pool.ConnMaxLifetime, pool.ConnMaxIdleTime = config.Duration(lifetime), config.Duration(idleTime)
+		if pool != (config.Pool{}) {
+			conn.Pool = &pool
+		}
+		var password string
+		if storePass {
+			if password, err = app.PromptPassword("Password for " + name + ": "); err != nil {
+				return err
+			}
+		}
+		if err := app.AddConnection(name, conn, password); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "saved connection %s (%s)\n", name, conn.Driver)
+		return nil
+	}
+}
+
+func connPasswordCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			return usagef("expected a connection name")
+		}
+		name := args[0]
+		if !app.IsConnection(name) {
+			return fmt.Errorf("no saved connection %q (binsql conn list shows them)", name)
+		}
+		password, err := app.PromptPassword("Password for " + name + ": ")
+		if err != nil {
+			return err
+		}
+		if err := app.SetConnectionPassword(name, password); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "stored password for %s\n", name)
+		return nil
+	}
+}
+
+func connTestCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var (
+		c       connFlags
+		timeout time.Duration
+	)
+	c.register(fs)
+	timeoutFlag(fs, &timeout)
+	return func(ctx context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		return app.TestConnection(ctx, conn, os.Stdout)
+	}
+}
+
+func connShowCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	var c connFlags
+	c.register(fs)
+	return func(_ context.Context, args []string) error {
+		conn, rest, err := c.resolve(args)
+		if err != nil {
+			return err
+		}
+		if err := noArgs(rest); err != nil {
+			return err
+		}
+		return app.ShowConnection(os.Stdout, conn)
+	}
+}
+
+func completionCommand(fs *flag.FlagSet) func(context.Context, []string) error {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			return usagef("expected one of bash, zsh or fish")
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			return usagef("unknown shell %q (expected bash, zsh or fish)", args[0])
+		}
+		return nil
+	}
+}
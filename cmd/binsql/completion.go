@@ -0,0 +1,263 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The completion scripts are generated from the command table, so new
+// commands and flags complete without touching them. Flag values that
+// complete to something specific are listed here.
+var (
+	flagChoices = map[string]string{
+		"driver":    "sqlite postgres mssql mysql",
+		"to-driver": "sqlite postgres mssql mysql",
+		"format":    "table tsv csv json",
+		"tls-mode":  "disable prefer require verify-ca verify-full",
+	}
+	argChoices    = map[string]string{"completion": "bash zsh fish"} // positional arguments
+	connFlagNames = map[string]bool{"conn": true, "to-conn": true}   // complete saved connection names
+	fileFlagNames = map[string]bool{
+		"file": true, "out": true, "ssh-key": true, "ssh-known-hosts": true,
+		"tls-ca": true, "tls-cert": true, "tls-key": true,
+	}
+)
+
+// compFlag is a flag as the completion scripts see it.
+type compFlag struct {
+	long, short, usage string
+	value              bool // takes an argument
+}
+
+// commandFlags lists the flags cmd registers.
+func commandFlags(cmd *command) []compFlag {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.setup(fs)
+	shorts := map[string]string{}
+	var out []compFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if long, ok := strings.CutPrefix(f.Usage, aliasPrefix); ok {
+			shorts[long] = f.Name
+			return
+		}
+		_, usage := flag.UnquoteUsage(f)
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		out = append(out, compFlag{long: f.Name, usage: usage, value: !(isBool && b.IsBoolFlag())})
+	})
+	for i := range out {
+		out[i].short = shorts[out[i].long]
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].long < out[j].long })
+	return out
+}
+
+// leafCommands calls fn for every runnable command with its path, e.g.
+// "conn add".
+func leafCommands(fn func(path string, cmd *command)) {
+	for _, c := range commands {
+		if c.subs == nil {
+			fn(c.name, c)
+			continue
+		}
+		for _, sub := range c.subs {
+			fn(c.name+" "+sub.name, sub)
+		}
+	}
+}
+
+func commandNames(cmds []*command) string {
+	names := make([]string, len(cmds))
+	for i, c := range cmds {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for binsql; load with: source <(binsql completion bash)")
+	fmt.Fprintln(w, "_binsql() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	case "$prev" in`)
+	for _, name := range sortedKeys(flagChoices) {
+		fmt.Fprintf(w, "	--%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, flagChoices[name])
+	}
+	fmt.Fprintln(w, `	--conn|--to-conn) COMPREPLY=($(compgen -W "$(binsql conn list --names 2>/dev/null)" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `	-f|--file|-o|--out|--ssh-key|--ssh-known-hosts|--tls-ca|--tls-cert|--tls-key) COMPREPLY=($(compgen -f -- "$cur")); return ;;`)
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, `	if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(w, "		COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n", commandNames(commands))
+	fmt.Fprintln(w, "	fi")
+	fmt.Fprintln(w, `	local cmd="${COMP_WORDS[1]}"`)
+	for _, c := range commands {
+		if c.subs != nil {
+			fmt.Fprintf(w, "	if [ \"$cmd\" = %s ] && [ \"$COMP_CWORD\" -eq 2 ]; then\n", c.name)
+			fmt.Fprintf(w, "		COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n", commandNames(c.subs))
+			fmt.Fprintln(w, "	fi")
+			fmt.Fprintf(w, "	[ \"$cmd\" = %s ] && cmd=\"$cmd ${COMP_WORDS[2]}\"\n", c.name)
+		}
+	}
+	for _, name := range sortedKeys(argChoices) {
+		fmt.Fprintf(w, "	[ \"$cmd\" = %s ] && [[ \"$cur\" != -* ]] && COMPREPLY=($(compgen -W %q -- \"$cur\")) && return\n", name, argChoices[name])
+	}
+	fmt.Fprintln(w, `	[[ "$cur" == -* ]] || return`)
+	fmt.Fprintln(w, `	case "$cmd" in`)
+	leafCommands(func(path string, cmd *command) {
+		var words []string
+		for _, f := range commandFlags(cmd) {
+			words = append(words, "--"+f.long)
+		}
+		if len(words) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "	%q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", path, strings.Join(words, " "))
+	})
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _binsql binsql")
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef binsql")
+	fmt.Fprintln(w, "# zsh completion for binsql; load with: source <(binsql completion zsh)")
+	fmt.Fprintln(w, "_binsql_conns() { compadd -- ${(f)\"$(binsql conn list --names 2>/dev/null)\"} }")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_binsql() {")
+	fmt.Fprintln(w, "	local -a cmds")
+	fmt.Fprintln(w, "	cmds=(")
+	for _, c := range commands {
+		fmt.Fprintf(w, "		%s\n", zshQuote(c.name+":"+c.summary))
+	}
+	fmt.Fprintln(w, "	)")
+	fmt.Fprintln(w, "	if (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "		_describe command cmds; return")
+	fmt.Fprintln(w, "	fi")
+	fmt.Fprintln(w, "	local cmd=$words[2]")
+	fmt.Fprintln(w, "	shift words; (( CURRENT-- ))")
+	for _, c := range commands {
+		if c.subs == nil {
+			continue
+		}
+		fmt.Fprintf(w, "	if [[ $cmd == %s ]]; then\n", c.name)
+		fmt.Fprintln(w, "		if (( CURRENT == 2 )); then")
+		fmt.Fprintln(w, "			local -a subs")
+		fmt.Fprint(w, "			subs=(")
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, " %s", zshQuote(sub.name+":"+sub.summary))
+		}
+		fmt.Fprintln(w, " )")
+		fmt.Fprintln(w, "			_describe command subs; return")
+		fmt.Fprintln(w, "		fi")
+		fmt.Fprintln(w, `		cmd="$cmd $words[2]"; shift words; (( CURRENT-- ))`)
+		fmt.Fprintln(w, "	fi")
+	}
+	fmt.Fprintln(w, "	case $cmd in")
+	leafCommands(func(path string, cmd *command) {
+		fmt.Fprintf(w, "	%q)\n", path)
+		fmt.Fprintln(w, "		_arguments -s \\")
+		for _, f := range commandFlags(cmd) {
+			fmt.Fprintf(w, "			%s \\\n", zshSpec(f))
+		}
+		if choices, ok := argChoices[path]; ok {
+			fmt.Fprintf(w, "			':%s:(%s)' ;;\n", path, choices)
+			return
+		}
+		fmt.Fprintln(w, "			'*:file:_files' ;;")
+	})
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `if [[ $funcstack[1] == _binsql ]]; then _binsql "$@"; else compdef _binsql binsql; fi`)
+}
+
+// zshSpec is an _arguments spec: '--format[output format]:format:(table csv)'.
+func zshSpec(f compFlag) string {
+	names := "--" + f.long
+	if f.short != "" {
+		names = "{-" + f.short + ",--" + f.long + "}"
+	}
+	desc := strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`).Replace(f.usage)
+	spec := "[" + desc + "]"
+	if f.value {
+		switch {
+		case flagChoices[f.long] != "":
+			spec += ":" + f.long + ":(" + flagChoices[f.long] + ")"
+		case connFlagNames[f.long]:
+			spec += ":connection:_binsql_conns"
+		case fileFlagNames[f.long]:
+			spec += ":file:_files"
+		default:
+			spec += ":" + f.long + ": "
+		}
+	}
+	if f.short != "" {
+		return names + zshQuote(spec)
+	}
+	return zshQuote(names + spec)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for binsql; load with: binsql completion fish | source")
+	fmt.Fprintln(w, "complete -c binsql -f")
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c binsql -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+	for _, c := range commands {
+		if c.subs == nil {
+			continue
+		}
+		cond := fmt.Sprintf("__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s", c.name, commandNames(c.subs))
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, "complete -c binsql -n %s -a %s -d %s\n", fishQuote(cond), sub.name, fishQuote(sub.summary))
+		}
+	}
+	leafCommands(func(path string, cmd *command) {
+		cond := "__fish_seen_subcommand_from " + path
+		if parent, sub, ok := strings.Cut(path, " "); ok {
+			cond = "__fish_seen_subcommand_from " + parent + "; and __fish_seen_subcommand_from " + sub
+		}
+		for _, f := range commandFlags(cmd) {
+			line := fmt.Sprintf("complete -c binsql -n %s -l %s", fishQuote(cond), f.long)
+			if f.short != "" {
+				line += " -s " + f.short
+			}
+			if f.value {
+				switch {
+				case flagChoices[f.long] != "":
+					line += " -x -a " + fishQuote(flagChoices[f.long])
+				case connFlagNames[f.long]:
+					line += " -x -a '(binsql conn list --names 2>/dev/null)'"
+				case fileFlagNames[f.long]:
+					line += " -r -F"
+				default:
+					line += " -x"
+				}
+			}
+			fmt.Fprintln(w, line+" -d "+fishQuote(f.usage))
+		}
+		if choices, ok := argChoices[path]; ok {
+			fmt.Fprintf(w, "complete -c binsql -n %s -a %s\n", fishQuote(cond), fishQuote(choices))
+			return
+		}
+		fmt.Fprintf(w, "complete -c binsql -n %s -F\n", fishQuote(cond))
+	})
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
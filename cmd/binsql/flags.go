@@ -0,0 +1,232 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/app"
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
+)
+
+// usageError is a bad flag or argument: binsql prints it with the
+// command's usage and exits with exitUsage.
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return &usageError{fmt.Sprintf(format, args...)}
+}
+
+// parseArgs parses fs from args, allowing flags after positional
+// arguments ("binsql query prod 'select 1' --format csv"). Everything
+// after "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var pos []string
+	for {
+		_ = fs.Parse(args) // ExitOnError
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(pos, rest...)
+		}
+		if len(rest) == 0 {
+			return pos
+		}
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+}
+
+// alias registers short as another name for the flag long.
+func alias(fs *flag.FlagSet, short, long string) {
+	fs.Var(fs.Lookup(long).Value, short, aliasPrefix+long)
+}
+
+const aliasPrefix = "alias for --"
+
+// printFlags lists the flags of fs as "-f, --file path  usage".
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	shorts := map[string]string{} // long name -> alias
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		if long, ok := strings.CutPrefix(f.Usage, aliasPrefix); ok {
+			shorts[long] = f.Name
+			return
+		}
+		flags = append(flags, f)
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	for _, f := range flags {
+		arg, usage := flag.UnquoteUsage(f)
+		name := "    --" + f.Name
+		if s, ok := shorts[f.Name]; ok {
+			name = "-" + s + ", --" + f.Name
+		}
+		if arg != "" {
+			name += " " + arg
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0s" {
+			usage += fmt.Sprintf(" (default %q)", f.DefValue)
+		}
+		fmt.Fprintf(w, "  %-24s %s\n", name, usage)
+	}
+}
+
+// connFlags selects the database: a saved connection or a driver and
+// DSN.
+type connFlags struct {
+	prefix string // "to-" for the second database of diff
+	conn   string
+	driver string
+	dsn    string
+
+	timeouts *connTimeouts // shared by both databases of diff
+}
+
+func (c *connFlags) register(fs *flag.FlagSet) {
+	what := "the database"
+	if c.prefix != "" {
+		what = "the database to compare with"
+	}
+	fs.StringVar(&c.conn, c.prefix+"conn", "", "saved connection `name` for "+what+" (see binsql conn)")
+	fs.StringVar(&c.driver, c.prefix+"driver", "", "`driver` for "+what+": sqlite, postgres, mssql or mysql")
+	fs.StringVar(&c.dsn, c.prefix+"dsn", "", "database path or `dsn` for "+what)
+	if c.timeouts == nil {
+		c.timeouts = &connTimeouts{}
+		c.timeouts.register(fs)
+	}
+}
+
+// connTimeouts are --connect-timeout and --query-timeout. They override
+// a saved connection's.
+type connTimeouts struct {
+	connect, query time.Duration
+}
+
+func (t *connTimeouts) register(fs *flag.FlagSet) {
+	fs.DurationVar(&t.connect, "connect-timeout", 0, "give up connecting after `duration` (default 5s)")
+	fs.DurationVar(&t.query, "query-timeout", 0, "cancel each query after `duration`, on the server too where it can (default none)")
+}
+
+func (t *connTimeouts) apply(conn *app.Conn) error {
+	if t.connect < 0 || t.query < 0 {
+		return usagef("timeouts cannot be negative")
+	}
+	if t.connect > 0 {
+		conn.ConnectTimeout = t.connect
+	}
+	if t.query > 0 {
+		conn.QueryTimeout = t.query
+	}
+	return nil
+}
+
+// resolve finds the database from the flags, or else from the leading
+// positional arguments: a saved connection name, "<driver> <dsn>" or a
+// DSN alone. Without a driver it is detected from the DSN; without any,
+// the environment may name one (app.EnvConnection). It returns the
+// arguments left over, and applies --connect-timeout and --query-timeout.
+func (c *connFlags) resolve(args []string) (app.Conn, []string, error) {
+	conn, rest, err := c.find(args)
+	if err == nil && c.timeouts != nil {
+		err = c.timeouts.apply(&conn)
+	}
+	return conn, rest, err
+}
+
+func (c *connFlags) find(args []string) (app.Conn, []string, error) {
+	switch {
+	case c.conn != "":
+		if c.driver != "" || c.dsn != "" {
+			return app.Conn{}, nil, usagef("--%sconn cannot be combined with --%sdriver or --%sdsn", c.prefix, c.prefix, c.prefix)
+		}
+		conn, err := app.LookupConnection(c.conn)
+		return conn, args, err
+
+	case c.driver != "":
+		driver, err := app.ParseDriver(c.driver)
+		if err != nil {
+			return app.Conn{}, nil, usagef("%v", err)
+		}
+		dsn := c.dsn
+		if dsn == "" {
+			switch {
+			case len(args) > 0:
+				dsn, args = args[0], args[1:]
+			case !app.AllowsEmptyDSN(driver):
+				return app.Conn{}, nil, usagef("missing --%sdsn", c.prefix)
+			}
+		}
+		return app.Conn{Driver: driver, DSN: dsn}, args, nil
+
+	case c.dsn != "":
+		driver, err := app.DetectDriver(c.dsn)
+		if err != nil {
+			return app.Conn{}, nil, usagef("%v; give --%sdriver", err, c.prefix)
+		}
+		return app.Conn{Driver: driver, DSN: c.dsn}, args, nil
+	}
+
+	if len(args) == 0 {
+		if conn, ok := app.EnvConnection(); ok {
+			return conn, args, nil
+		}
+		return app.Conn{}, nil, usagef("no database given")
+	}
+	if driver, err := app.ParseDriver(args[0]); err == nil {
+		if len(args) < 2 {
+			return app.Conn{}, nil, usagef("missing database path or DSN after %s", args[0])
+		}
+		return app.Conn{Driver: driver, DSN: args[1]}, args[2:], nil
+	}
+	if app.IsConnection(args[0]) {
+		conn, err := app.LookupConnection(args[0])
+		return conn, args[1:], err
+	}
+	driver, err := app.DetectDriver(args[0])
+	if err != nil {
+		// Not a database: leave it to the command (e.g. the SQL of
+		// "binsql query") if the environment names one.
+		if conn, ok := app.EnvConnection(); ok {
+			return conn, args, nil
+		}
+		return app.Conn{}, nil, usagef("%q is not a driver or saved connection, and %v", args[0], err)
+	}
+	return app.Conn{Driver: driver, DSN: args[0]}, args[1:], nil
+}
+
+// outputFlags are shared by the commands that print results.
+func outputFlags(fs *flag.FlagSet, opts *app.QueryOptions, format string) {
+	fs.StringVar(&opts.Format, "format", format, "output `format`: table, tsv, csv or json")
+	fs.StringVar(&cellfmt.NullGlyph, "null", cellfmt.NullGlyph, "`text` shown for SQL NULL in table output")
+}
+
+func echoFlag(fs *flag.FlagSet, opts *app.QueryOptions) {
+	fs.BoolVar(&opts.Echo, "echo", false, "print the (highlighted) query before its result")
+}
+
+// paramFlag adds a repeatable --param name=value.
+func paramFlag(fs *flag.FlagSet, opts *app.QueryOptions) {
+	fs.Func("param", "bind :name in the query, as `name=value` (repeatable)", func(v string) error {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected name=value, got %q", v)
+		}
+		if opts.Params == nil {
+			opts.Params = map[string]string{}
+		}
+		opts.Params[name] = value
+		return nil
+	})
+	alias(fs, "p", "param")
+}
+
+// timeoutFlag adds --timeout; the command's context gets the deadline.
+func timeoutFlag(fs *flag.FlagSet, d *time.Duration) {
+	fs.DurationVar(d, "timeout", 0, "give up after `duration`, e.g. 30s (default none)")
+}
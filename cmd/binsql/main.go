@@ -3,15 +3,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"golang.org/x/term"
 
 	"github.com/bgunnarsson/binsql/internal/app"
 )
 
+// Exit codes, the same for every command.
+const (
+	exitOK    = 0
+	exitError = 1 // a query or the command failed
+	exitUsage = 2 // bad flags or arguments
+	exitConn  = 3 // the database could not be reached
+)
+
 func main() {
 	// Workaround: azidentity/AzureCLICredential treats any stderr as error.
 	// Azure CLI on macOS+Py3.12 spews SyntaxWarning to stderr. Kill them.
@@ -20,47 +31,163 @@ func main() {
 		os.Setenv("PYTHONWARNINGS", "ignore")
 	}
 
-	var query string
-	flag.StringVar(&query, "q", "", "SQL query to run in non-interactive mode")
-	flag.Parse()
+	os.Exit(dispatch(os.Args[1:]))
+}
+
+// dispatch runs a subcommand, or the original "binsql [flags] <driver>
+// <dsn>" form when the first argument is not one.
+func dispatch(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := findCommand(commands, args[1]); cmd != nil {
+				return runCommand(cmd, "binsql "+cmd.name, []string{"-h"})
+			}
+		}
+		usage(os.Stdout)
+		return exitOK
+	}
+	if cmd := findCommand(commands, args[0]); cmd != nil {
+		return runCommand(cmd, "binsql "+cmd.name, args[1:])
+	}
+	return legacy(args)
+}
+
+// dbHelp explains the <db> argument.
+const dbHelp = `<db> is a saved connection name (or --conn), a database file or DSN
+(--dsn) whose driver is detected, or <driver> <dsn> (--driver, --dsn).
+Drivers: sqlite, postgres, mssql, mysql.`
 
-	if flag.NArg() < 2 {
-		fmt.Fprintln(os.Stderr, "usage: binsql [flags] <sqlite|postgres|mssql|mysql> <database-path-or-dsn>")
-		flag.PrintDefaults()
-		os.Exit(2)
+// usage lists the commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: binsql <command> [flags] [args]")
+	fmt.Fprintln(w, "       binsql [-q <sql>] [<driver>] <dsn>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, "  %-12s %s\n", c.name+" "+sub.name, sub.summary)
+		}
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, dbHelp)
+	fmt.Fprintln(w, `Run "binsql help <command>" for its flags.`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 ok, 1 query or command failed, 2 usage, 3 connection failed.")
+}
 
-	driverStr := flag.Arg(0)
-	dsn := flag.Arg(1)
+// runCommand parses args for cmd (or one of its subcommands) and runs
+// it. name is the command path, e.g. "binsql conn add".
+func runCommand(cmd *command, name string, args []string) int {
+	if cmd.subs != nil {
+		subUsage := func(w io.Writer) {
+			fmt.Fprintf(w, "usage: %s <command> [flags] [args]\n\nCommands:\n", name)
+			for _, sub := range cmd.subs {
+				fmt.Fprintf(w, "  %-8s %s\n", sub.name, sub.summary)
+			}
+		}
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			subUsage(os.Stdout)
+			return exitOK
+		}
+		var sub *command
+		if len(args) > 0 {
+			sub = findCommand(cmd.subs, args[0])
+		}
+		if sub == nil {
+			subUsage(os.Stderr)
+			return exitUsage
+		}
+		return runCommand(sub, name+" "+sub.name, args[1:])
+	}
 
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "usage: %s [flags] %s\n\n%s.\n", name, cmd.args, cmd.summary)
+		if strings.Contains(cmd.args, "<db>") {
+			fmt.Fprintln(w, "\n"+dbHelp)
+		}
+		fmt.Fprintln(w, "\nFlags:")
+		printFlags(w, fs)
+	}
+	run := cmd.setup(fs)
+	err := run(context.Background(), parseArgs(fs, args))
+
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n\n", name, err)
+		fs.Usage()
+		return exitUsage
+	}
+	return report(err)
+}
+
+// report prints err and maps it to an exit code.
+func report(err error) int {
+	var connErr *app.ConnectError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errDiffers):
+		return exitError
+	case errors.As(err, &connErr):
+		fmt.Fprintln(os.Stderr, "error: connecting:", err)
+		return exitConn
+	}
+	fmt.Fprintln(os.Stderr, "error:", err)
+	return exitError
+}
+
+// legacy is the original command line: the TUI, or one query with -q or
+// when stdout is not a terminal.
+func legacy(args []string) int {
+	fs := flag.NewFlagSet("binsql", flag.ExitOnError)
+	var query string
+	var opts app.QueryOptions
+	fs.StringVar(&query, "q", "", "SQL query to run in non-interactive mode")
+	fs.BoolVar(&opts.Echo, "echo", false, "print the (highlighted) query before its result in non-interactive mode")
+	fs.StringVar(&opts.Format, "format", "table", "non-interactive output format: table, tsv, csv or json")
+	fs.Usage = func() {
+		usage(fs.Output())
+		fmt.Fprintln(fs.Output(), "\nFlags of the [<driver>] <dsn> form:")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if cmd := findCommand(commands, fs.Arg(0)); cmd != nil {
+		fmt.Fprintf(os.Stderr, "binsql: flags go after the command: binsql %s [flags] %s\n", cmd.name, cmd.args)
+		return exitUsage
+	}
 	var driver app.Driver
-	switch driverStr {
-	case string(app.DriverSqlite):
-		driver = app.DriverSqlite
-	case string(app.DriverPostgres):
-		driver = app.DriverPostgres
-	case string(app.DriverMssql):
-		driver = app.DriverMssql
-	case string(app.DriverMysql):
-		driver = app.DriverMysql	
+	var dsn string
+	var err error
+	switch fs.NArg() {
+	case 1:
+		dsn = fs.Arg(0)
+		driver, err = app.DetectDriver(dsn)
+	case 2:
+		dsn = fs.Arg(1)
+		driver, err = app.ParseDriver(fs.Arg(0))
 	default:
-		fmt.Fprintf(os.Stderr, "unknown driver %q (expected sqlite, postgres, mssql or mysql)\n", driverStr)
-		os.Exit(2)
+		fs.Usage()
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
 	}
 
 	ctx := context.Background()
 	stdoutIsTTY := term.IsTerminal(int(os.Stdout.Fd()))
 
 	if query != "" || !stdoutIsTTY {
-		if err := app.RunNonInteractive(ctx, driver, dsn, query); err != nil {
-			fmt.Fprintln(os.Stderr, "error:", err)
-			os.Exit(1)
-		}
-		return
-	}
-
-	if err := app.RunInteractive(ctx, driver, dsn); err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		os.Exit(1)
+		return report(app.RunNonInteractive(ctx, app.Conn{Driver: driver, DSN: dsn}, query, opts))
 	}
+	return report(app.RunInteractive(ctx, app.Conn{Driver: driver, DSN: dsn}))
 }
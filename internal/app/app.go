@@ -2,13 +2,23 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
 
+	"github.com/bgunnarsson/binsql/internal/config"
 	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/health"
 	"github.com/bgunnarsson/binsql/internal/db/mssql"
 	"github.com/bgunnarsson/binsql/internal/db/mysql"
 	"github.com/bgunnarsson/binsql/internal/db/postgres"
+	"github.com/bgunnarsson/binsql/internal/db/schemacache"
 	"github.com/bgunnarsson/binsql/internal/db/sqlite"
+	"github.com/bgunnarsson/binsql/internal/sshtunnel"
 	"github.com/bgunnarsson/binsql/internal/ui"
 )
 
@@ -21,34 +31,245 @@ const (
 	DriverMysql    Driver = "mysql"
 )
 
-// central factory
-func openDB(driver Driver, dsn string) (db.DB, error) {
-	switch driver {
-	case "", DriverSqlite:
-		return sqlite.Open(dsn)
+// schemaTTL is how often the TUI's schema cache refreshes itself.
+const schemaTTL = 10 * time.Minute
+
+// Drivers lists the supported drivers.
+var Drivers = []Driver{DriverSqlite, DriverPostgres, DriverMssql, DriverMysql}
+
+// ParseDriver checks that s names a supported driver.
+func ParseDriver(s string) (Driver, error) {
+	for _, d := range Drivers {
+		if s == string(d) {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("unknown driver %q (expected sqlite, postgres, mssql or mysql)", s)
+}
+
+// ConnectError is returned when the database could not be opened or
+// reached, as opposed to a statement failing once connected.
+type ConnectError struct {
+	Err error
+}
+
+func (e *ConnectError) Error() string { return e.Err.Error() }
+func (e *ConnectError) Unwrap() error { return e.Err }
+
+// openDB is the central factory. Its errors are ConnectErrors, with
+// DSNs redacted. An empty driver is detected from the DSN, and the DSN
+// is completed from the environment (see ResolveConnection). With an
+// SSH jump host the driver connects through a tunnel that is closed with
+// the returned DB. When the server rejects a DSN without a password,
+// binsql asks for one on the terminal and tries again. With a query
+// timeout every query gets a deadline (see timeoutDB), and SELECTs that
+// hit a broken connection are retried once (see withHealth).
+func openDB(c Conn) (db.DB, error) {
+	r, err := ResolveConnection(c.Driver, c.DSN)
+	if err != nil {
+		return nil, &ConnectError{redactError(err, c.DSN)}
+	}
+	o, err := dbOptions(r, c)
+	if err != nil {
+		return nil, &ConnectError{err}
+	}
+	var tunnel *sshtunnel.Tunnel
+	if c.SSH != nil {
+		if tunnel, err = openTunnel(r, c.SSH); err != nil {
+			return nil, &ConnectError{redactError(err, c.DSN, r.DSN)}
+		}
+	}
+	d, err := open(r, o)
+	if err != nil && !r.Info.Password && isAuthError(r.Driver, err) {
+		if pw, perr := readPassword("Password for " + r.Target() + ": "); perr == nil {
+			if r.DSN, err = withPassword(r.Driver, r.DSN, pw); err == nil {
+				d, err = open(r, o)
+			}
+		}
+	}
+	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
+		return nil, &ConnectError{redactError(err, c.DSN, r.DSN)}
+	}
+	if tunnel != nil {
+		d = &tunneledDB{DB: d, tunnel: tunnel}
+	}
+	if c.QueryTimeout > 0 {
+		d = &timeoutDB{DB: d, driver: r.Driver, timeout: c.QueryTimeout}
+	}
+	return withHealth(d, r.Driver), nil
+}
+
+// open opens a resolved connection with the adapter settings o.
+func open(r *Resolved, o db.Options) (db.DB, error) {
+	switch r.Driver {
+	case DriverSqlite:
+		if path := sqlitePath(r.DSN); path != "" {
+			if err := checkSQLiteFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return sqlite.Open(r.DSN)
 	case DriverPostgres:
-		return postgres.Open(dsn)
+		return postgres.Open(r.DSN, o)
 	case DriverMssql:
-		return mssql.Open(dsn)
+		return mssql.Open(r.DSN, o)
 	case DriverMysql:
-		return mysql.Open(dsn)
-	default:
-		return nil, fmt.Errorf("unsupported driver %q", driver)
+		return mysql.Open(r.DSN, o)
 	}
+	return nil, fmt.Errorf("unsupported driver %q", r.Driver)
 }
 
-func RunInteractive(ctx context.Context, driver Driver, dsn string) error {
-	sdb, err := openDB(driver, dsn)
+// RunInteractive runs the TUI on conn. When the user opens another
+// connection (saved or typed in) from the TUI, it is connected and the
+// TUI runs again on it; if that fails, the TUI comes back on the current
+// connection with the error in the status bar.
+func RunInteractive(ctx context.Context, conn Conn) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	sess, err := openSession(conn)
 	if err != nil {
 		return err
 	}
-	defer sdb.Close()
+	notice := ""
+	for {
+		next, err := sess.run(ctx, cfg, notice)
+		if err != nil || next == nil {
+			sess.close()
+			return err
+		}
+		notice = ""
+		ns, err := openNext(*next)
+		if err != nil {
+			what := next.Name
+			if what == "" {
+				what = "the DSN"
+			}
+			notice = fmt.Sprintf("Could not open %s: %v", what, err)
+			continue
+		}
+		sess.close()
+		sess = ns
+	}
+}
+
+// session is an open connection the TUI runs on.
+type session struct {
+	driver   Driver
+	dsn      string
+	target   string
+	insecure bool
+	db       db.DB
+	cache    *schemacache.Cache
+}
+
+// openSession opens conn; an empty driver is detected from the DSN.
+func openSession(conn Conn) (*session, error) {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return nil, err
+	}
+	dsn := conn.DSN
 
-	// Label for prompt/header
-	label := "sqlite"
-	if driver != "" {
-		label = string(driver)
+	r, err := ResolveConnection(driver, dsn)
+	if err != nil {
+		return nil, &ConnectError{redactError(err, dsn)}
+	}
+	target := r.Target()
+	if conn.SSH != nil {
+		target += " via " + sshTarget(conn.SSH)
 	}
+	sdb, err := openDB(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catalog cache, persisted per connection for instant startup.
+	cache := schemacache.New(sdb, schemacache.Options{
+		Path: schemacache.PathFor(string(driver), dsn),
+		TTL:  schemaTTL,
+	})
+	return &session{
+		driver:   driver,
+		dsn:      dsn,
+		target:   target,
+		insecure: unverifiedTLS(r, conn),
+		db:       sdb,
+		cache:    cache,
+	}, nil
+}
 
-	return ui.Run(ctx, sdb, label)
+// openNext opens what the user picked in the TUI.
+func openNext(c ui.Connection) (*session, error) {
+	if c.Name != "" {
+		conn, err := LookupConnection(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		return openSession(conn)
+	}
+	conn := Conn{DSN: c.DSN}
+	if c.Driver != "" {
+		var err error
+		if conn.Driver, err = ParseDriver(c.Driver); err != nil {
+			return nil, err
+		}
+	}
+	return openSession(conn)
+}
+
+// run runs the TUI until it quits. next is the connection the user
+// asked to open instead, if any.
+func (s *session) run(ctx context.Context, cfg *config.Config, notice string) (next *ui.Connection, err error) {
+	// The cache refresh and health check stop with the TUI.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	names, _ := connectionNames()
+	hdb, _ := s.db.(*health.DB)
+	tls := describeTLS(ctx, s.db)
+	err = ui.Run(ctx, s.cache, string(s.driver), ui.Options{
+		PrefsPath:   prefsPath(s.driver, s.dsn),
+		Target:      s.target,
+		TLS:         tls,
+		Insecure:    s.insecure && tls != "no TLS", // prefer may fall back to plain text
+		Health:      hdb,
+		Theme:       cfg.Theme,
+		ThemeDir:    config.ThemeDir(),
+		Keys:        cfg.Keys,
+		Notice:      notice,
+		Connections: names,
+		Open:        func(c ui.Connection) { next = &c },
+	})
+	return next, err
+}
+
+func (s *session) close() { s.db.Close() }
+
+// connectionNames lists the saved connections.
+func connectionNames() ([]string, error) {
+	cs, err := config.LoadConnections()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.Name
+	}
+	return names, nil
+}
+
+// prefsPath is the per-connection UI preferences file. The DSN is hashed
+// so credentials never end up in file names.
+func prefsPath(driver Driver, dsn string) string {
+	dir := config.Dir()
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(string(driver) + "\x00" + dsn))
+	return filepath.Join(dir, "connections", hex.EncodeToString(sum[:8])+".json")
 }
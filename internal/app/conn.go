@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+)
+
+// Conn is a database to open: a driver and DSN, and what a saved
+// connection adds that a DSN cannot say.
+type Conn struct {
+	Driver Driver // empty: detected from DSN
+	DSN    string
+	SSH    *config.SSH // jump host to tunnel through
+	TLS    *config.TLS // replaces the DSN's TLS parameters
+
+	ConnectTimeout time.Duration // 0: the adapter default
+	QueryTimeout   time.Duration // 0: none
+	Pool           *config.Pool
+}
+
+// LookupConnection returns the saved connection name. A password kept
+// in the credentials file is added to the DSN.
+func LookupConnection(name string) (Conn, error) {
+	cs, err := config.LoadConnections()
+	if err != nil {
+		return Conn{}, err
+	}
+	c, ok := config.FindConnection(cs, name)
+	if !ok {
+		return Conn{}, fmt.Errorf("no saved connection %q (binsql conn list shows them)", name)
+	}
+	driver, err := ParseDriver(c.Driver)
+	if err != nil {
+		return Conn{}, fmt.Errorf("connection %s: %w", name, err)
+	}
+	conn := Conn{
+		Driver:         driver,
+		DSN:            c.DSN,
+		SSH:            c.SSH,
+		TLS:            c.TLS,
+		ConnectTimeout: time.Duration(c.ConnectTimeout),
+		QueryTimeout:   time.Duration(c.QueryTimeout),
+		Pool:           c.Pool,
+	}
+	if c.Credential == "" {
+		return conn, nil
+	}
+	pw, err := credential(c.Credential)
+	if err != nil {
+		return Conn{}, fmt.Errorf("connection %s: %w", name, err)
+	}
+	// Resolve first: the password goes into the DSN the driver is given.
+	r, err := ResolveConnection(driver, c.DSN)
+	if err != nil {
+		return Conn{}, fmt.Errorf("connection %s: %w", name, redactError(err, c.DSN))
+	}
+	if conn.DSN, err = withPassword(driver, r.DSN, pw); err != nil {
+		return Conn{}, fmt.Errorf("connection %s: %w", name, err)
+	}
+	return conn, nil
+}
+
+// IsConnection reports whether name is a saved connection.
+func IsConnection(name string) bool {
+	cs, _ := config.LoadConnections()
+	_, ok := config.FindConnection(cs, name)
+	return ok
+}
+
+// AddConnection saves conn under name. Relative SQLite, key and
+// certificate paths are made absolute so the connection works from any
+// directory. A non-empty
+// password is kept in the encrypted credentials file rather than in the
+// DSN.
+func AddConnection(name string, conn Conn, password string) error {
+	dsn := conn.DSN
+	if conn.Driver == DriverSqlite && !strings.HasPrefix(dsn, "file:") && sqlitePath(dsn) != "" {
+		abs, err := filepath.Abs(dsn)
+		if err != nil {
+			return err
+		}
+		dsn = abs
+	}
+	if conn.SSH != nil {
+		if conn.Driver == DriverSqlite {
+			return errors.New("sqlite databases cannot be reached over SSH")
+		}
+		ssh := *conn.SSH
+		for _, path := range []*string{&ssh.KeyFile, &ssh.KnownHosts} {
+			if *path != "" && !strings.HasPrefix(*path, "~/") {
+				abs, err := filepath.Abs(*path)
+				if err != nil {
+					return err
+				}
+				*path = abs
+			}
+		}
+		conn.SSH = &ssh
+	}
+	if conn.TLS != nil {
+		if conn.Driver == DriverSqlite {
+			return errors.New("sqlite connections have no TLS")
+		}
+		t := *conn.TLS
+		for _, path := range []*string{&t.CAFile, &t.CertFile, &t.KeyFile} {
+			if *path != "" {
+				abs, err := filepath.Abs(*path)
+				if err != nil {
+					return err
+				}
+				*path = abs
+			}
+		}
+		if err := CheckTLS(&t); err != nil {
+			return err
+		}
+		conn.TLS = &t
+	}
+	if conn.Pool != nil {
+		if conn.Driver == DriverSqlite {
+			return errors.New("sqlite connections use a single connection, not a pool")
+		}
+		if err := CheckPool(conn.Pool); err != nil {
+			return err
+		}
+	}
+	c := config.Connection{
+		Name:           name,
+		Driver:         string(conn.Driver),
+		DSN:            dsn,
+		SSH:            conn.SSH,
+		TLS:            conn.TLS,
+		ConnectTimeout: config.Duration(conn.ConnectTimeout),
+		QueryTimeout:   config.Duration(conn.QueryTimeout),
+		Pool:           conn.Pool,
+	}
+	if password != "" {
+		if conn.Driver == DriverSqlite {
+			return errors.New("sqlite connections have no password")
+		}
+		if err := StoreCredential(name, password); err != nil {
+			return err
+		}
+		c.Credential = name
+	}
+	return config.SaveConnection(c)
+}
+
+// SetConnectionPassword stores password for the saved connection name in
+// the credentials file and makes the connection use it.
+func SetConnectionPassword(name, password string) error {
+	cs, err := config.LoadConnections()
+	if err != nil {
+		return err
+	}
+	c, ok := config.FindConnection(cs, name)
+	if !ok {
+		return fmt.Errorf("no saved connection %q (binsql conn list shows them)", name)
+	}
+	if c.Driver == string(DriverSqlite) {
+		return errors.New("sqlite connections have no password")
+	}
+	if c.Credential == "" {
+		c.Credential = name
+	}
+	if err := StoreCredential(c.Credential, password); err != nil {
+		return err
+	}
+	return config.SaveConnection(c)
+}
+
+// ListConnections prints the saved connections, or only their names.
+func ListConnections(w io.Writer, namesOnly bool) error {
+	cs, err := config.LoadConnections()
+	if err != nil {
+		return err
+	}
+	if namesOnly {
+		for _, c := range cs {
+			fmt.Fprintln(w, c.Name)
+		}
+		return nil
+	}
+	if len(cs) == 0 {
+		fmt.Fprintln(w, "no saved connections (binsql conn add <name> <driver> <dsn>)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDRIVER\tDSN")
+	for _, c := range cs {
+		dsn := RedactDSN(c.DSN)
+		if c.Credential != "" {
+			dsn += " (stored password)"
+		}
+		if c.SSH != nil {
+			dsn += " via ssh " + sshTarget(c.SSH)
+		}
+		if c.TLS != nil {
+			dsn += " tls " + tlsMode(c.TLS)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, c.Driver, dsn)
+	}
+	return tw.Flush()
+}
+
+// TestConnection connects and lists the tables, reporting how many there
+// are and how long it took.
+func TestConnection(ctx context.Context, conn Conn, w io.Writer) error {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return &ConnectError{err}
+	}
+	start := time.Now()
+	sdb, err := openDB(conn)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	tables, err := sdb.ListTables(ctx)
+	if err != nil {
+		return &ConnectError{err}
+	}
+	fmt.Fprintf(w, "ok: %s, %d tables, %s\n", driver, len(tables), time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/db/mssql"
+	"github.com/bgunnarsson/binsql/internal/db/mysql"
+	"github.com/bgunnarsson/binsql/internal/db/postgres"
+)
+
+// passphraseEnv holds the credentials passphrase for scripts; without it
+// binsql asks on the terminal.
+const passphraseEnv = "BINSQL_PASSPHRASE"
+
+var errNoTerminal = errors.New("no terminal to prompt on")
+
+// readPassword prompts on the terminal and reads a line without echo.
+// It uses /dev/tty when stdin is a pipe, so it works under "binsql query
+// < file.sql" too.
+func readPassword(prompt string) (string, error) {
+	in := os.Stdin
+	if !term.IsTerminal(int(in.Fd())) {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return "", errNoTerminal
+		}
+		defer tty.Close()
+		in = tty
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// PromptPassword asks for a password on the terminal, e.g. for "binsql
+// conn add --store-password".
+func PromptPassword(prompt string) (string, error) {
+	pw, err := readPassword(prompt)
+	if errors.Is(err, errNoTerminal) {
+		return "", errors.New("cannot ask for a password: no terminal")
+	}
+	return pw, err
+}
+
+// passphrase is the credentials passphrase, read once per run.
+var passphrase string
+
+// credentialsPassphrase returns the passphrase from BINSQL_PASSPHRASE or
+// the terminal. confirm asks twice, for when the file is created.
+func credentialsPassphrase(confirm bool) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if p := os.Getenv(passphraseEnv); p != "" {
+		passphrase = p
+		return p, nil
+	}
+	p, err := readPassword("Credentials passphrase: ")
+	if errors.Is(err, errNoTerminal) {
+		return "", fmt.Errorf("the credentials file is encrypted: set %s or run from a terminal", passphraseEnv)
+	}
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := readPassword("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	passphrase = p
+	return p, nil
+}
+
+// credential returns the stored password called name.
+func credential(name string) (string, error) {
+	p, err := credentialsPassphrase(false)
+	if err != nil {
+		return "", err
+	}
+	creds, err := config.LoadCredentials(p)
+	if err != nil {
+		return "", err
+	}
+	pw, ok := creds[name]
+	if !ok {
+		return "", fmt.Errorf("no stored password %q in %s", name, config.CredentialsPath())
+	}
+	return pw, nil
+}
+
+// StoreCredential saves password under name in the credentials file,
+// creating it (and asking for its passphrase twice) if needed.
+func StoreCredential(name, password string) error {
+	p, err := credentialsPassphrase(!config.HasCredentials())
+	if err != nil {
+		return err
+	}
+	creds, err := config.LoadCredentials(p)
+	if err != nil {
+		return err
+	}
+	creds[name] = password
+	return config.SaveCredentials(creds, p)
+}
+
+// withPassword sets the password of a resolved DSN.
+func withPassword(driver Driver, dsn, password string) (string, error) {
+	switch driver {
+	case DriverPostgres:
+		return postgres.WithPassword(dsn, password)
+	case DriverMysql:
+		return mysql.WithPassword(dsn, password)
+	case DriverMssql:
+		return mssql.WithPassword(dsn, password)
+	}
+	return "", fmt.Errorf("%s connections have no password", driver)
+}
+
+// isAuthError reports whether err is the server asking for a (different)
+// password.
+func isAuthError(driver Driver, err error) bool {
+	switch driver {
+	case DriverPostgres:
+		return postgres.IsAuthError(err)
+	case DriverMysql:
+		return mysql.IsAuthError(err)
+	case DriverMssql:
+		return mssql.IsAuthError(err)
+	}
+	return false
+}
+
+// redactedError is err with DSNs in its message redacted.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError hides the passwords of dsns where they appear in err.
+func redactError(err error, dsns ...string) error {
+	msg := err.Error()
+	for _, dsn := range dsns {
+		if r := RedactDSN(dsn); dsn != "" && r != dsn {
+			msg = strings.ReplaceAll(msg, dsn, r)
+		}
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg, err}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sqliteMagic starts every SQLite 3 database file.
+const sqliteMagic = "SQLite format 3\x00"
+
+// ResolveDriver returns driver, or the one detected from dsn when it is
+// empty.
+func ResolveDriver(driver Driver, dsn string) (Driver, error) {
+	if driver != "" {
+		return driver, nil
+	}
+	return DetectDriver(dsn)
+}
+
+// DetectDriver infers the driver from a DSN: its URL scheme, the keys of
+// a key=value connection string, the "user@tcp(host)/db" form of mysql,
+// or a file starting with the SQLite header. A file that does not exist
+// yet counts as SQLite by its extension.
+func DetectDriver(dsn string) (Driver, error) {
+	s := strings.ToLower(strings.TrimSpace(dsn))
+	if s == "" {
+		return "", errors.New("empty DSN")
+	}
+
+	if scheme, _, ok := strings.Cut(s, "://"); ok {
+		switch scheme {
+		case "postgres", "postgresql":
+			return DriverPostgres, nil
+		case "sqlserver":
+			return DriverMssql, nil
+		case "mysql":
+			return DriverMysql, nil
+		}
+		return "", fmt.Errorf("cannot tell the driver from URL scheme %q (expected postgres, sqlserver or mysql)", scheme)
+	}
+	if s == ":memory:" || strings.HasPrefix(s, "file:") {
+		return DriverSqlite, nil
+	}
+	if strings.Contains(s, "@tcp(") || strings.Contains(s, "@unix(") || strings.HasPrefix(s, "tcp(") {
+		return DriverMysql, nil
+	}
+
+	switch _, err := os.Stat(dsn); {
+	case err == nil:
+		if err := checkSQLiteFile(dsn); err != nil {
+			return "", err
+		}
+		return DriverSqlite, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", err
+	}
+
+	if d := keyValueDriver(s); d != "" {
+		return d, nil
+	}
+	switch filepath.Ext(s) {
+	case ".db", ".sqlite", ".sqlite3", ".db3":
+		return DriverSqlite, nil
+	}
+	return "", fmt.Errorf("cannot tell the driver from %q (no such file, and not a URL or connection string)", RedactDSN(dsn))
+}
+
+// keyValueDriver recognises "server=db;database=app" (mssql, pairs
+// separated by semicolons) and "host=db dbname=app" (postgres, separated
+// by spaces) connection strings.
+func keyValueDriver(s string) Driver {
+	sep := func(r rune) bool { return r == ' ' || r == '\t' }
+	if strings.Contains(s, ";") {
+		sep = func(r rune) bool { return r == ';' }
+	}
+	keys := map[string]bool{}
+	for _, field := range strings.FieldsFunc(s, sep) {
+		if k, _, ok := strings.Cut(field, "="); ok {
+			keys[strings.TrimSpace(k)] = true
+		}
+	}
+	for _, k := range []string{"server", "data source", "address", "initial catalog", "database", "fedauth"} {
+		if keys[k] {
+			return DriverMssql
+		}
+	}
+	for _, k := range []string{"host", "hostaddr", "dbname", "sslmode", "service"} {
+		if keys[k] {
+			return DriverPostgres
+		}
+	}
+	return ""
+}
+
+// checkSQLiteFile reads the first 16 bytes of path to make sure it is a
+// SQLite database before it is opened. Empty files are fine: SQLite
+// initialises them.
+func checkSQLiteFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	head := make([]byte, len(sqliteMagic))
+	n, err := io.ReadFull(f, head)
+	if n == 0 && err == io.EOF {
+		return nil
+	}
+	if string(head[:n]) != sqliteMagic {
+		return fmt.Errorf("%s is not a SQLite database", path)
+	}
+	return nil
+}
+
+// sqlitePath is the file a sqlite DSN opens; "" for in-memory databases.
+func sqlitePath(dsn string) string {
+	path := strings.TrimPrefix(dsn, "file:")
+	path, _, _ = strings.Cut(path, "?")
+	if path == ":memory:" || path == "" {
+		return ""
+	}
+	return path
+}
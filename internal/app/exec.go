@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// Exec runs a script statement by statement (split at semicolons),
+// printing the result of every statement that returns columns. It stops
+// at the first failing statement.
+func Exec(ctx context.Context, conn Conn, script string, opts QueryOptions) error {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return &ConnectError{err}
+	}
+	script, err = bindParams(driver, script, opts)
+	if err != nil {
+		return err
+	}
+	stmts := sqllex.Split(script, sqllex.DialectFor(string(driver)))
+	if len(stmts) == 0 {
+		return fmt.Errorf("no statements to run")
+	}
+
+	sdb, err := openDB(conn)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	printed := false
+	for i, stmt := range stmts {
+		if opts.Echo {
+			echoQuery(driver, stmt)
+		}
+		rows, err := sdb.Query(ctx, stmt)
+		if err != nil {
+			if len(stmts) == 1 {
+				return err
+			}
+			return fmt.Errorf("statement %d of %d: %w", i+1, len(stmts), err)
+		}
+		if len(rows.Columns) == 0 {
+			continue
+		}
+		if printed && (opts.Format == "" || opts.Format == "table") {
+			fmt.Fprintln(os.Stdout)
+		}
+		if err := render(rows, opts.Format); err != nil {
+			return err
+		}
+		printed = true
+	}
+	return nil
+}
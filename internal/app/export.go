@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bgunnarsson/binsql/internal/print"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// Export runs query and writes the full result to path ("-" or "" for
+// stdout). The format is opts.Format, or taken from the file extension
+// when that is empty.
+func Export(ctx context.Context, conn Conn, query, path string, opts QueryOptions) error {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return &ConnectError{err}
+	}
+	query, err = bindParams(driver, query, opts)
+	if err != nil {
+		return err
+	}
+	format := opts.Format
+	if format == "" {
+		format = print.FormatForPath(path)
+	}
+
+	sdb, err := openDB(conn)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	rows, err := sdb.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if path == "" || path == "-" {
+		return print.Render(os.Stdout, rows, format, print.Options{})
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := print.Render(f, rows, format, print.Options{}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows to %s\n", len(rows.Data), path)
+	return nil
+}
+
+// TableQuery selects all of table, a name that may be qualified
+// ("schema.table"), with each part quoted for conn's driver.
+func TableQuery(conn Conn, table string) (string, error) {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return "", &ConnectError{err}
+	}
+	return "SELECT * FROM " + sqllex.QuoteQualified(table, sqllex.DialectFor(string(driver))), nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/health"
+	"github.com/bgunnarsson/binsql/internal/db/mysql"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// healthInterval is how often the TUI pings the server.
+const healthInterval = 15 * time.Second
+
+// withHealth puts d behind a health.DB, which retries SELECTs that hit a
+// broken connection once. The TUI also starts its background ping.
+func withHealth(d db.DB, driver Driver) *health.DB {
+	dialect := sqllex.DialectFor(string(driver))
+	return health.New(d, health.Options{
+		Interval:    healthInterval,
+		IsConnError: func(err error) bool { return isConnError(driver, err) },
+		Retryable:   func(query string) bool { return sqllex.IsSelect(query, dialect) },
+	})
+}
+
+// isConnError reports whether err is the connection breaking rather than
+// the statement failing.
+func isConnError(driver Driver, err error) bool {
+	if driver == DriverMysql && mysql.IsConnError(err) {
+		return true
+	}
+	return health.IsConnError(err)
+}
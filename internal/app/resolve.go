@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/mssql"
+	"github.com/bgunnarsson/binsql/internal/db/mysql"
+	"github.com/bgunnarsson/binsql/internal/db/postgres"
+)
+
+// Resolved is a connection after driver detection and DSN completion.
+type Resolved struct {
+	Driver Driver
+	DSN    string // what the driver is given
+	Info   db.ConnInfo
+}
+
+// ResolveConnection detects the driver when it is empty and completes
+// dsn from the environment and the native clients' config files, the
+// way psql and mysql would (see postgres.Resolve and mysql.Resolve).
+func ResolveConnection(driver Driver, dsn string) (*Resolved, error) {
+	driver, err := ResolveDriver(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolved{Driver: driver, DSN: dsn}
+	switch driver {
+	case DriverPostgres:
+		r.DSN, r.Info, err = postgres.Resolve(dsn)
+	case DriverMysql:
+		r.DSN, r.Info, err = mysql.Resolve(dsn)
+	case DriverMssql:
+		r.DSN, r.Info, err = mssql.Resolve(dsn)
+	case DriverSqlite:
+		r.Info.Database = sqlitePath(dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Target describes the connection without its password, e.g.
+// "app@db.internal:5432/shop", for prompts and the TUI header.
+func (r *Resolved) Target() string {
+	if r.Driver == DriverSqlite {
+		if r.Info.Database == "" {
+			return ":memory:"
+		}
+		return r.Info.Database
+	}
+	s := r.Info.Host
+	if r.Info.Port != "" {
+		s = net.JoinHostPort(s, r.Info.Port)
+	}
+	if r.Info.User != "" {
+		s = r.Info.User + "@" + s
+	}
+	if r.Info.Database != "" {
+		s += "/" + r.Info.Database
+	}
+	return s
+}
+
+// EnvConnection is the database the environment names when none is
+// given: DATABASE_URL, or postgres when PGHOST, PGDATABASE or PGSERVICE
+// is set.
+func EnvConnection() (Conn, bool) {
+	if u := os.Getenv("DATABASE_URL"); u != "" {
+		if driver, err := DetectDriver(u); err == nil {
+			return Conn{Driver: driver, DSN: u}, true
+		}
+	}
+	for _, name := range []string{"PGHOST", "PGDATABASE", "PGSERVICE"} {
+		if os.Getenv(name) != "" {
+			return Conn{Driver: DriverPostgres}, true
+		}
+	}
+	return Conn{}, false
+}
+
+// AllowsEmptyDSN reports whether driver can connect from the
+// environment alone.
+func AllowsEmptyDSN(driver Driver) bool {
+	return driver == DriverPostgres || driver == DriverMysql
+}
+
+var (
+	urlPassword   = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*://[^:@/]*):[^@/]*@`)
+	mysqlPassword = regexp.MustCompile(`^([^:@/]*):.*@((tcp|unix)\(|/)`)
+	kvPassword    = regexp.MustCompile(`(?i)\b(password|pwd)(\s*=\s*)('(?:[^'\\]|\\.)*'|"(?:[^"]|"")*"|\{(?:[^}]|\}\})*\}|[^;\s]*)`)
+)
+
+// RedactDSN hides the password in a URL (user info and password or pwd
+// query parameters), mysql or key=value DSN.
+func RedactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			u.RawQuery = redactQuery(u.RawQuery)
+			return u.Redacted()
+		}
+		dsn = urlPassword.ReplaceAllString(dsn, "$1:xxxxx@")
+	}
+	dsn = mysqlPassword.ReplaceAllString(dsn, "$1:xxxxx@$2")
+	return kvPassword.ReplaceAllString(dsn, "$1${2}xxxxx")
+}
+
+// redactQuery hides password and pwd parameters in a URL query, keeping
+// the order and encoding of the rest.
+func redactQuery(q string) string {
+	if q == "" {
+		return q
+	}
+	parts := strings.Split(q, "&")
+	for i, p := range parts {
+		k, _, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		if name, err := url.QueryUnescape(k); err == nil &&
+			(strings.EqualFold(name, "password") || strings.EqualFold(name, "pwd")) {
+			parts[i] = k + "=xxxxx"
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// ShowConnection prints a resolved connection with its password
+// redacted, and where the settings that are not in the DSN came from.
+func ShowConnection(w io.Writer, conn Conn) error {
+	r, err := ResolveConnection(conn.Driver, conn.DSN)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	row := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", k, v)
+		}
+	}
+	row("driver", string(r.Driver))
+	row("host", r.Info.Host)
+	row("port", r.Info.Port)
+	row("database", r.Info.Database)
+	row("user", r.Info.User)
+	if r.Driver != DriverSqlite {
+		password := "none"
+		if r.Info.Password {
+			password = "set"
+		}
+		row("password", password)
+	}
+	row("from", strings.Join(r.Info.Sources, ", "))
+	if conn.SSH != nil {
+		row("ssh", sshTarget(conn.SSH))
+	}
+	if conn.TLS != nil {
+		row("tls", tlsSummary(conn.TLS))
+	}
+	if conn.ConnectTimeout > 0 {
+		row("connect timeout", conn.ConnectTimeout.String())
+	}
+	if conn.QueryTimeout > 0 {
+		row("query timeout", queryTimeoutSummary(r.Driver, conn.QueryTimeout))
+	}
+	if conn.Pool != nil {
+		row("pool", poolSummary(conn.Pool))
+	}
+	row("dsn", RedactDSN(r.DSN))
+	return tw.Flush()
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"url user info", "postgres://bob:s3cret@db:5432/app", "postgres://bob:xxxxx@db:5432/app"},
+		{"url no password", "postgres://bob@db/app?sslmode=require", "postgres://bob@db/app?sslmode=require"},
+		{"url query password", "postgres://db/app?password=s3cret", "postgres://db/app?password=xxxxx"},
+		{"url query mixed case", "postgres://db/app?sslmode=disable&PassWord=s3cret&x=1",
+			"postgres://db/app?sslmode=disable&PassWord=xxxxx&x=1"},
+		{"url query encoded key", "postgres://db/app?pass%77ord=s3cret", "postgres://db/app?pass%77ord=xxxxx"},
+		{"url both", "postgres://bob:a@db/app?password=b", "postgres://bob:xxxxx@db/app?password=xxxxx"},
+		{"sqlserver url", "sqlserver://sa@host:1433?database=app&password=s3cret",
+			"sqlserver://sa@host:1433?database=app&password=xxxxx"},
+		{"sqlserver url pwd", "sqlserver://host?PWD=s3cret", "sqlserver://host?PWD=xxxxx"},
+		{"mysql url", "mysql://root:s3cret@db:3306/app", "mysql://root:xxxxx@db:3306/app"},
+		{"unparsable url", "postgres://bob:s3%zz@db/app", "postgres://bob:xxxxx@db/app"},
+		{"mysql tcp", "root:s3cret@tcp(127.0.0.1:3306)/app", "root:xxxxx@tcp(127.0.0.1:3306)/app"},
+		{"mysql unix", "root:s3cret@unix(/tmp/mysql.sock)/app", "root:xxxxx@unix(/tmp/mysql.sock)/app"},
+		{"mysql default host", "root:s3cret@/app", "root:xxxxx@/app"},
+		{"mysql password with @", "root:p@ss@tcp(db)/app", "root:xxxxx@tcp(db)/app"},
+		{"mysql no password", "root@tcp(db)/app", "root@tcp(db)/app"},
+		{"ado", "Server=db;Database=app;User Id=sa;Password=s3cret;Encrypt=true",
+			"Server=db;Database=app;User Id=sa;Password=xxxxx;Encrypt=true"},
+		{"ado quoted", `Server=db;Password="s3;cret";`, `Server=db;Password=xxxxx;`},
+		{"odbc braces", "odbc:server=db;pwd={s3;c}}ret};database=app", "odbc:server=db;pwd=xxxxx;database=app"},
+		{"keyword value", "host=db user=bob password=s3cret dbname=app", "host=db user=bob password=xxxxx dbname=app"},
+		{"keyword value quoted", "host=db password='s3 cret' dbname=app", "host=db password=xxxxx dbname=app"},
+		{"keyword value spaces", "host=db password = s3cret", "host=db password = xxxxx"},
+		{"sqlite file", "./app.db", "./app.db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactDSN(tt.dsn)
+			if got != tt.want {
+				t.Errorf("RedactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+			}
+			if strings.Contains(got, "s3") {
+				t.Errorf("RedactDSN(%q) = %q leaks the password", tt.dsn, got)
+			}
+		})
+	}
+}
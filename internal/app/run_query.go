@@ -2,9 +2,19 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 
+	"golang.org/x/term"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/library"
 	"github.com/bgunnarsson/binsql/internal/print"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+	"github.com/bgunnarsson/binsql/internal/ui"
 )
 
 // defaultListQuery returns the driver-specific "list tables" SQL used
@@ -34,30 +44,93 @@ WHERE table_type = 'BASE TABLE'
   AND table_schema = DATABASE()
 ORDER BY table_name;
 `
-	case "", DriverSqlite:
+	case DriverSqlite:
 		fallthrough
 	default:
 		return "select name from sqlite_master where type = 'table' order by name;"
 	}
 }
 
+// QueryOptions controls non-interactive output.
+type QueryOptions struct {
+	Echo   bool   // print the (highlighted) query before the result
+	Format string // table (default), tsv, csv or json
+
+	// Params binds :name placeholders in the query (see library.Bind).
+	// Without params the query is sent as written.
+	Params map[string]string
+}
+
+// bindParams substitutes opts.Params into query.
+func bindParams(driver Driver, query string, opts QueryOptions) (string, error) {
+	if len(opts.Params) == 0 {
+		return query, nil
+	}
+	return library.Parse("query", query).Bind(opts.Params, sqllex.DialectFor(string(driver)))
+}
 
-func RunNonInteractive(ctx context.Context, driver Driver, dsn, query string) error {
+func RunNonInteractive(ctx context.Context, conn Conn, query string, opts QueryOptions) error {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return &ConnectError{err}
+	}
 	if query == "" {
 		query = defaultListQuery(driver)
 	}
+	query, err = bindParams(driver, query, opts)
+	if err != nil {
+		return err
+	}
 
-	sdb, err := openDB(driver, dsn)
+	sdb, err := openDB(conn)
 	if err != nil {
 		return err
 	}
 	defer sdb.Close()
 
+	if opts.Echo {
+		echoQuery(driver, query)
+	}
+
 	rows, err := sdb.Query(ctx, query)
 	if err != nil {
 		return err
 	}
 
-	print.RenderTable(os.Stdout, rows, print.Options{MaxWidth: 60})
-	return nil
+	return render(rows, opts.Format)
+}
+
+// render prints rows to stdout in format.
+func render(rows *db.Rows, format string) error {
+	return print.Render(os.Stdout, rows, format, print.Options{
+		MaxWidth: 60,
+		Color:    useColor(),
+	})
+}
+
+// useColor reports whether stdout is a terminal and NO_COLOR is not set.
+func useColor() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+}
+
+// echoTheme points --echo's highlighting at the configured theme.
+var echoTheme sync.Once
+
+// echoQuery prints the query being run, highlighted in the theme's
+// colours when stdout is a terminal and NO_COLOR is not set.
+func echoQuery(driver Driver, query string) {
+	query = strings.TrimSpace(query)
+	if useColor() {
+		echoTheme.Do(func() {
+			cfg, err := config.Load()
+			if err != nil {
+				return // keep the plain 16-colour codes
+			}
+			if colors := ui.EchoColors(cfg.Theme, config.ThemeDir()); colors != nil {
+				sqllex.ANSIColors = colors
+			}
+		})
+		query = sqllex.ANSI(query, sqllex.DialectFor(string(driver)))
+	}
+	fmt.Fprintln(os.Stdout, query)
 }
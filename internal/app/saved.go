@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgunnarsson/binsql/internal/library"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// RunSaved runs the saved query name (see package library) with params
+// bound, printing like RunNonInteractive.
+func RunSaved(ctx context.Context, conn Conn, name string, params map[string]string, opts QueryOptions) error {
+	driver, err := ResolveDriver(conn.Driver, conn.DSN)
+	if err != nil {
+		return &ConnectError{err}
+	}
+	qs, err := library.Load()
+	if err != nil {
+		return err
+	}
+	q, ok := library.Find(qs, name)
+	if !ok {
+		return fmt.Errorf("no saved query %q (binsql run -list shows them)", name)
+	}
+	if !q.Matches(string(driver)) {
+		return fmt.Errorf("saved query %s is for %s, not %s", q.Name, q.Driver, driver)
+	}
+	sql, err := q.Bind(params, sqllex.DialectFor(string(driver)))
+	if err != nil {
+		return err
+	}
+	opts.Params = nil // bound above
+	conn.Driver = driver
+	return RunNonInteractive(ctx, conn, sql, opts)
+}
+
+// ListSaved prints the saved queries: name, where it comes from, tags,
+// parameters and description.
+func ListSaved(w io.Writer) error {
+	qs, err := library.Load()
+	if err != nil {
+		return err
+	}
+	if len(qs) == 0 {
+		fmt.Fprintf(w, "no saved queries (add .sql files to %s or .binsql/queries/)\n", library.UserDir())
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSOURCE\tTAGS\tPARAMS\tDESCRIPTION")
+	for _, q := range qs {
+		params := make([]string, len(q.Params))
+		for i, p := range q.Params {
+			params[i] = p.Name
+			if t := p.Decl(); t != "" {
+				params[i] += ":" + t
+			}
+			if p.HasDefault {
+				params[i] += "=" + p.Default
+			}
+		}
+		source := q.Source
+		if q.Driver != "" {
+			source += ", " + q.Driver
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", q.Name, source,
+			strings.Join(q.Tags, ","), strings.Join(params, " "), q.Description)
+	}
+	return tw.Flush()
+}
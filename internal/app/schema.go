@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Schema prints the tables of the database, or the columns of table.
+func Schema(ctx context.Context, conn Conn, table string, opts QueryOptions) error {
+	sdb, err := openDB(conn)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	out := &db.Rows{}
+	if table == "" {
+		tables, err := sdb.ListTables(ctx)
+		if err != nil {
+			return err
+		}
+		out.Columns = []db.Column{{Name: "name"}}
+		for _, t := range tables {
+			out.Data = append(out.Data, db.Row{t})
+		}
+	} else {
+		cols, err := sdb.DescribeTable(ctx, table)
+		if err != nil {
+			return err
+		}
+		if len(cols) == 0 {
+			return fmt.Errorf("no table %q", table)
+		}
+		out.Columns = []db.Column{{Name: "column"}, {Name: "type"}}
+		for _, c := range cols {
+			out.Data = append(out.Data, db.Row{c.Name, c.Type})
+		}
+	}
+	return render(out, opts.Format)
+}
+
+// DiffSchemas compares the tables and columns of two databases and
+// writes the differences to w, one per line: "- table t" and
+// "- t.col type" for what only from has, "+ …" for what only to has,
+// and "~ t.col type -> type" for a changed column type. It reports
+// whether there were any.
+func DiffSchemas(ctx context.Context, fromConn, toConn Conn, w io.Writer) (bool, error) {
+	from, err := loadSchema(ctx, fromConn)
+	if err != nil {
+		return false, err
+	}
+	to, err := loadSchema(ctx, toConn)
+	if err != nil {
+		return false, err
+	}
+
+	differ := false
+	for _, t := range unionKeys(from, to) {
+		a, inFrom := from[t]
+		b, inTo := to[t]
+		switch {
+		case !inTo:
+			fmt.Fprintf(w, "- table %s\n", t)
+		case !inFrom:
+			fmt.Fprintf(w, "+ table %s\n", t)
+		default:
+			for _, c := range unionKeys(a, b) {
+				ta, okA := a[c]
+				tb, okB := b[c]
+				switch {
+				case !okB:
+					fmt.Fprintf(w, "- %s.%s %s\n", t, c, ta)
+				case !okA:
+					fmt.Fprintf(w, "+ %s.%s %s\n", t, c, tb)
+				case ta != tb:
+					fmt.Fprintf(w, "~ %s.%s %s -> %s\n", t, c, ta, tb)
+				default:
+					continue
+				}
+				differ = true
+			}
+			continue
+		}
+		differ = true
+	}
+	return differ, nil
+}
+
+// loadSchema reads table -> column -> type.
+func loadSchema(ctx context.Context, conn Conn) (map[string]map[string]string, error) {
+	sdb, err := openDB(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer sdb.Close()
+
+	tables, err := sdb.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schema := make(map[string]map[string]string, len(tables))
+	for _, t := range tables {
+		cols, err := sdb.DescribeTable(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t, err)
+		}
+		schema[t] = make(map[string]string, len(cols))
+		for _, c := range cols {
+			schema[t][c.Name] = c.Type
+		}
+	}
+	return schema, nil
+}
+
+// unionKeys returns the keys of a and b, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
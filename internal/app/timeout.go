@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/mssql"
+	"github.com/bgunnarsson/binsql/internal/db/mysql"
+	"github.com/bgunnarsson/binsql/internal/db/postgres"
+)
+
+// dbOptions collects a connection's adapter settings: TLS (see dbTLS),
+// timeouts and pool. Through an SSH tunnel, TLS the DSN asks for is
+// checked against the database host too.
+func dbOptions(r *Resolved, c Conn) (db.Options, error) {
+	t, err := dbTLS(r, c)
+	if err != nil {
+		return db.Options{}, err
+	}
+	o := db.Options{
+		TLS:            t,
+		ConnectTimeout: c.ConnectTimeout,
+		QueryTimeout:   c.QueryTimeout,
+	}
+	if c.SSH != nil {
+		o.ServerName = tunneledHost(r)
+	}
+	if c.Pool != nil {
+		o.Pool = db.Pool{
+			MaxOpenConns:    c.Pool.MaxOpenConns,
+			MaxIdleConns:    c.Pool.MaxIdleConns,
+			ConnMaxLifetime: time.Duration(c.Pool.ConnMaxLifetime),
+			ConnMaxIdleTime: time.Duration(c.Pool.ConnMaxIdleTime),
+		}
+	}
+	return o, nil
+}
+
+// CheckPool validates pool settings before they are saved.
+func CheckPool(p *config.Pool) error {
+	switch {
+	case p.MaxOpenConns < 0, p.MaxIdleConns < 0, p.ConnMaxLifetime < 0, p.ConnMaxIdleTime < 0:
+		return errors.New("pool settings cannot be negative")
+	case p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns:
+		return fmt.Errorf("max idle connections (%d) exceed max open connections (%d)", p.MaxIdleConns, p.MaxOpenConns)
+	}
+	return nil
+}
+
+// poolSummary describes pool settings for conn show, e.g. "max open 8,
+// max lifetime 1m0s".
+func poolSummary(p *config.Pool) string {
+	var parts []string
+	if p.MaxOpenConns > 0 {
+		parts = append(parts, fmt.Sprintf("max open %d", p.MaxOpenConns))
+	}
+	if p.MaxIdleConns > 0 {
+		parts = append(parts, fmt.Sprintf("max idle %d", p.MaxIdleConns))
+	}
+	if p.ConnMaxLifetime > 0 {
+		parts = append(parts, "max lifetime "+time.Duration(p.ConnMaxLifetime).String())
+	}
+	if p.ConnMaxIdleTime > 0 {
+		parts = append(parts, "max idle time "+time.Duration(p.ConnMaxIdleTime).String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// QueryTimeoutError is a query that ran past the query timeout, on the
+// client or the server.
+type QueryTimeoutError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("query timed out after %s", e.After)
+}
+
+func (e *QueryTimeoutError) Unwrap() error { return e.Err }
+
+// timeoutDB puts a deadline on each query and reports running out of
+// time as a QueryTimeoutError rather than a driver or context error.
+// The adapters also push the timeout to the server where they can.
+type timeoutDB struct {
+	db.DB
+	driver  Driver
+	timeout time.Duration
+}
+
+func (t *timeoutDB) ListTables(ctx context.Context) ([]string, error) {
+	qctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	tables, err := t.DB.ListTables(qctx)
+	return tables, t.check(ctx, qctx, err)
+}
+
+func (t *timeoutDB) DescribeTable(ctx context.Context, table string) ([]db.Column, error) {
+	qctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	cols, err := t.DB.DescribeTable(qctx, table)
+	return cols, t.check(ctx, qctx, err)
+}
+
+func (t *timeoutDB) Query(ctx context.Context, sql string, args ...any) (*db.Rows, error) {
+	qctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	rows, err := t.DB.Query(qctx, sql, args...)
+	return rows, t.check(ctx, qctx, err)
+}
+
+// Unwrap exposes the adapter for optional interfaces (see db.Unwrap).
+func (t *timeoutDB) Unwrap() db.DB { return t.DB }
+
+// check turns err into a QueryTimeoutError when the query's own
+// deadline passed (not the caller's, e.g. --timeout) or the server
+// stopped it.
+func (t *timeoutDB) check(ctx, qctx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if errors.Is(qctx.Err(), context.DeadlineExceeded) || isTimeoutError(t.driver, err) {
+		return &QueryTimeoutError{After: t.timeout, Err: err}
+	}
+	return err
+}
+
+// isTimeoutError reports whether err is the server cancelling a query
+// for running past its statement timeout, or on SQL Server its lock
+// timeout.
+func isTimeoutError(driver Driver, err error) bool {
+	switch driver {
+	case DriverPostgres:
+		return postgres.IsTimeoutError(err)
+	case DriverMysql:
+		return mysql.IsTimeoutError(err)
+	case DriverMssql:
+		return mssql.IsTimeoutError(err)
+	}
+	return false
+}
+
+// queryTimeoutSummary describes a query timeout for conn show, with how
+// the server enforces it.
+func queryTimeoutSummary(driver Driver, d time.Duration) string {
+	switch driver {
+	case DriverPostgres:
+		return d.String() + " (statement_timeout)"
+	case DriverMysql:
+		return d.String() + " (max_execution_time, or max_statement_time on MariaDB)"
+	case DriverMssql:
+		return d.String() + " (cancelled by the client; LOCK_TIMEOUT on the server)"
+	}
+	return d.String() + " (cancelled by the client)"
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// dbTLS turns a saved connection's TLS settings into the adapters' form.
+// Through an SSH tunnel the DSN names the local end, so the certificate
+// is checked against the database host instead. Turning verification
+// off is warned about every time.
+func dbTLS(r *Resolved, c Conn) (*db.TLS, error) {
+	if c.TLS == nil {
+		return nil, nil
+	}
+	if r.Driver == DriverSqlite {
+		return nil, errors.New("sqlite connections have no TLS")
+	}
+	t := toDBTLS(c.TLS)
+	if t.ServerName == "" && c.SSH != nil {
+		t.ServerName = tunneledHost(r)
+	}
+	if t.SkipVerify && !t.Disabled() {
+		fmt.Fprintf(os.Stderr, "WARNING: TLS certificate verification is off for %s; anyone on the network path can read and change the traffic\n", r.Target())
+	}
+	return t, nil
+}
+
+// toDBTLS converts saved TLS settings.
+func toDBTLS(t *config.TLS) *db.TLS {
+	return &db.TLS{
+		Mode:       t.Mode,
+		CAFile:     t.CAFile,
+		CertFile:   t.CertFile,
+		KeyFile:    t.KeyFile,
+		ServerName: t.ServerName,
+		SkipVerify: t.SkipVerify,
+	}
+}
+
+// unverifiedTLS reports whether the connection may be encrypted without
+// the server certificate being checked: saved TLS settings, or else the
+// DSN's, in mode prefer or require or with verification turned off.
+func unverifiedTLS(r *Resolved, c Conn) bool {
+	t := r.Info.TLS
+	if c.TLS != nil {
+		t = toDBTLS(c.TLS)
+	}
+	return t != nil && !t.Disabled() && !t.Verified()
+}
+
+// tunneledHost is the database host an SSH tunnel leads to, without a
+// SQL Server instance name.
+func tunneledHost(r *Resolved) string {
+	host, _, _ := strings.Cut(r.Info.Host, `\`)
+	return host
+}
+
+// CheckTLS validates TLS settings before they are saved.
+func CheckTLS(t *config.TLS) error {
+	return toDBTLS(t).Check()
+}
+
+// tlsSummary describes saved TLS settings for conn list and conn show,
+// e.g. "verify-ca, ca /etc/ssl/db.pem".
+func tlsSummary(t *config.TLS) string {
+	parts := []string{tlsMode(t)}
+	if t.CAFile != "" {
+		parts = append(parts, "ca "+t.CAFile)
+	}
+	if t.CertFile != "" {
+		parts = append(parts, "client cert "+t.CertFile)
+	}
+	if t.ServerName != "" {
+		parts = append(parts, "server name "+t.ServerName)
+	}
+	if t.SkipVerify {
+		parts = append(parts, "NOT VERIFIED")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// tlsMode is t's mode with the default filled in.
+func tlsMode(t *config.TLS) string {
+	if t.Mode == "" {
+		return db.TLSVerifyFull
+	}
+	return t.Mode
+}
+
+// describeTLS is what the connection negotiated, e.g. "TLS 1.3
+// TLS_AES_128_GCM_SHA256" or "no TLS"; empty when the adapter cannot
+// tell.
+func describeTLS(ctx context.Context, sdb db.DB) string {
+	rep, ok := db.Unwrap(sdb).(db.TLSReporter)
+	if !ok {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	info, err := rep.TLSInfo(ctx)
+	switch {
+	case err != nil:
+		return ""
+	case info.Version == "":
+		return "no TLS"
+	}
+	return strings.TrimSpace(info.Version + " " + info.Cipher)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+)
+
+func TestUnverifiedTLS(t *testing.T) {
+	t.Setenv("PGSSLMODE", "")
+	tests := []struct {
+		name string
+		conn Conn
+		want bool
+	}{
+		{"postgres default", Conn{DSN: "postgres://db/app"}, true},
+		{"postgres require", Conn{DSN: "postgres://db/app?sslmode=require"}, true},
+		{"postgres verify-ca", Conn{DSN: "postgres://db/app?sslmode=verify-ca"}, false},
+		{"postgres verify-full", Conn{DSN: "postgres://db/app?sslmode=verify-full"}, false},
+		{"postgres disable", Conn{DSN: "postgres://db/app?sslmode=disable"}, false},
+		{"postgres keyword value", Conn{DSN: "host=db sslmode=require"}, true},
+		{"mysql skip-verify", Conn{DSN: "root@tcp(db)/app?tls=skip-verify"}, true},
+		{"mysql preferred", Conn{DSN: "root@tcp(db)/app?tls=preferred"}, true},
+		{"mysql true", Conn{DSN: "root@tcp(db)/app?tls=true"}, false},
+		{"mysql none", Conn{DSN: "root@tcp(db)/app"}, false},
+		{"mssql default", Conn{DSN: "sqlserver://db"}, true},
+		{"mssql trusted", Conn{DSN: "sqlserver://db?encrypt=true&TrustServerCertificate=true"}, true},
+		{"mssql encrypt", Conn{DSN: "sqlserver://db?encrypt=true"}, false},
+		{"mssql disable", Conn{DSN: "sqlserver://db?encrypt=disable"}, false},
+		{"sqlite", Conn{DSN: "./app.db"}, false},
+		{"saved require", Conn{DSN: "postgres://db/app?sslmode=verify-full",
+			TLS: &config.TLS{Mode: "require"}}, true},
+		{"saved skip verify", Conn{DSN: "postgres://db/app",
+			TLS: &config.TLS{SkipVerify: true}}, true},
+		{"saved verify-full", Conn{DSN: "postgres://db/app?sslmode=require",
+			TLS: &config.TLS{}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ResolveConnection("", tt.conn.DSN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := unverifiedTLS(r, tt.conn); got != tt.want {
+				t.Errorf("unverifiedTLS(%q) = %v, want %v", tt.conn.DSN, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/mssql"
+	"github.com/bgunnarsson/binsql/internal/db/mysql"
+	"github.com/bgunnarsson/binsql/internal/db/postgres"
+	"github.com/bgunnarsson/binsql/internal/sshtunnel"
+)
+
+// Default server ports, for DSNs that leave the port out.
+var defaultPorts = map[Driver]string{
+	DriverPostgres: "5432",
+	DriverMysql:    "3306",
+	DriverMssql:    "1433",
+}
+
+// openTunnel forwards a local port through the jump host to the server
+// r names, and points r.DSN at the local end. r.Info still describes the
+// server.
+func openTunnel(r *Resolved, s *config.SSH) (*sshtunnel.Tunnel, error) {
+	if r.Driver == DriverSqlite {
+		return nil, errors.New("sqlite databases cannot be reached over SSH")
+	}
+	host, port := r.Info.Host, r.Info.Port
+	if host == "" || host[0] == '/' {
+		return nil, errors.New("an SSH tunnel needs a TCP host in the DSN, not a socket")
+	}
+	if h, instance, ok := strings.Cut(host, `\`); ok {
+		if port == "" {
+			return nil, fmt.Errorf("an SSH tunnel to instance %s needs its port in the DSN", instance)
+		}
+		host = h
+	}
+	if port == "" {
+		port = defaultPorts[r.Driver]
+	}
+
+	t, err := sshtunnel.Open(sshtunnel.Config{
+		Host:       s.Host,
+		User:       s.User,
+		KeyFile:    s.KeyFile,
+		Agent:      s.Agent,
+		KnownHosts: s.KnownHosts,
+		Passphrase: readPassword,
+	}, net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	localHost, localPort, _ := net.SplitHostPort(t.LocalAddr())
+	if r.DSN, err = withAddress(r.Driver, r.DSN, localHost, localPort); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// withAddress points a resolved DSN at host:port.
+func withAddress(driver Driver, dsn, host, port string) (string, error) {
+	switch driver {
+	case DriverPostgres:
+		return postgres.WithAddress(dsn, host, port)
+	case DriverMysql:
+		return mysql.WithAddress(dsn, host, port)
+	case DriverMssql:
+		return mssql.WithAddress(dsn, host, port)
+	}
+	return "", errors.New("unsupported driver " + string(driver))
+}
+
+// sshTarget is "user@host" of a jump host.
+func sshTarget(s *config.SSH) string {
+	if s.User == "" {
+		return s.Host
+	}
+	return s.User + "@" + s.Host
+}
+
+// tunneledDB closes its SSH tunnel with the database.
+type tunneledDB struct {
+	db.DB
+	tunnel *sshtunnel.Tunnel
+}
+
+func (t *tunneledDB) Close() error {
+	err := t.DB.Close()
+	t.tunnel.Close()
+	return err
+}
+
+// Unwrap exposes the adapter for optional interfaces (see db.Unwrap).
+func (t *tunneledDB) Unwrap() db.DB { return t.DB }
@@ -0,0 +1,241 @@
+// Package blob inspects binary column values: content sniffing via magic
+// bytes, hex dumps and small decoded previews. It is shared by the TUI and
+// the table printer so BLOBs look the same everywhere.
+package blob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Kind string
+
+const (
+	KindEmpty    Kind = "empty"
+	KindText     Kind = "text"
+	KindUTF16LE  Kind = "utf-16le text"
+	KindUTF16BE  Kind = "utf-16be text"
+	KindPNG      Kind = "png"
+	KindJPEG     Kind = "jpeg"
+	KindGIF      Kind = "gif"
+	KindPDF      Kind = "pdf"
+	KindGzip     Kind = "gzip"
+	KindZip      Kind = "zip"
+	KindProtobuf Kind = "protobuf?"
+	KindBinary   Kind = "binary"
+)
+
+// Sniff guesses what b contains. Magic bytes win over heuristics.
+func Sniff(b []byte) Kind {
+	switch {
+	case len(b) == 0:
+		return KindEmpty
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return KindPNG
+	case bytes.HasPrefix(b, []byte{0xFF, 0xD8, 0xFF}):
+		return KindJPEG
+	case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+		return KindGIF
+	case bytes.HasPrefix(b, []byte("%PDF-")):
+		return KindPDF
+	case bytes.HasPrefix(b, []byte{0x1F, 0x8B}):
+		return KindGzip
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		return KindZip
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return KindUTF16LE
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return KindUTF16BE
+	}
+
+	if IsText(b) {
+		return KindText
+	}
+	if k, ok := sniffUTF16(b); ok {
+		return k
+	}
+	if looksLikeProtobuf(b) {
+		return KindProtobuf
+	}
+	return KindBinary
+}
+
+// IsText reports whether b is valid UTF-8 without control characters
+// other than tab, newline and carriage return.
+func IsText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < 32 && r != '\n' && r != '\t' && r != '\r' {
+			return false
+		}
+		if r == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffUTF16 detects BOM-less UTF-16 of mostly-ASCII text, which is what
+// NVARCHAR data looks like when it ends up in a binary column.
+func sniffUTF16(b []byte) (Kind, bool) {
+	if len(b) < 4 || len(b)%2 != 0 {
+		return "", false
+	}
+	var zeroEven, zeroOdd int
+	for i := 0; i < len(b); i += 2 {
+		if b[i] == 0 {
+			zeroEven++
+		}
+		if b[i+1] == 0 {
+			zeroOdd++
+		}
+	}
+	half := len(b) / 2
+	switch {
+	case zeroOdd*10 >= half*9 && zeroEven == 0:
+		return KindUTF16LE, true
+	case zeroEven*10 >= half*9 && zeroOdd == 0:
+		return KindUTF16BE, true
+	}
+	return "", false
+}
+
+// looksLikeProtobuf walks b as a sequence of protobuf wire-format fields
+// and succeeds only if the whole buffer parses.
+func looksLikeProtobuf(b []byte) bool {
+	if len(b) < 2 {
+		return false
+	}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 || key>>3 == 0 {
+			return false
+		}
+		b = b[n:]
+		switch key & 7 {
+		case 0: // varint
+			_, n := binary.Uvarint(b)
+			if n <= 0 {
+				return false
+			}
+			b = b[n:]
+		case 1: // 64-bit
+			if len(b) < 8 {
+				return false
+			}
+			b = b[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return false
+			}
+			b = b[n+int(l):]
+		case 5: // 32-bit
+			if len(b) < 4 {
+				return false
+			}
+			b = b[4:]
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Summary is the one-line form used in grids and printed tables.
+// Short binary values are shown as hex, longer ones as a size + type.
+func Summary(b []byte) string {
+	if len(b) <= 16 {
+		return "0x" + hex.EncodeToString(b)
+	}
+	kind := Sniff(b)
+	if kind == KindBinary {
+		return fmt.Sprintf("<blob %d bytes>", len(b))
+	}
+	return fmt.Sprintf("<blob %d bytes, %s>", len(b), kind)
+}
+
+// HexDump returns a hexdump -C style dump of at most max bytes (0 = all).
+func HexDump(b []byte, max int) string {
+	truncated := false
+	if max > 0 && len(b) > max {
+		b = b[:max]
+		truncated = true
+	}
+	out := hex.Dump(b)
+	if truncated {
+		out += fmt.Sprintf("… (first %d bytes shown)\n", max)
+	}
+	return out
+}
+
+// Gunzip decompresses b, reading at most limit bytes of output.
+func Gunzip(b []byte, limit int64) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, limit))
+}
+
+// DecodeUTF16 decodes b as UTF-16, skipping a leading BOM.
+func DecodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 && ((b[0] == 0xFF && b[1] == 0xFE) || (b[0] == 0xFE && b[1] == 0xFF)) {
+		b = b[2:]
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = binary.BigEndian.Uint16(b[2*i:])
+		} else {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+// Preview returns a human-readable decoding of b when its kind has one
+// (text, UTF-16, gzip-compressed content), and ok=false otherwise.
+func Preview(b []byte, limit int) (title, body string, ok bool) {
+	switch Sniff(b) {
+	case KindUTF16LE:
+		return "UTF-16LE text", DecodeUTF16(b, false), true
+	case KindUTF16BE:
+		return "UTF-16BE text", DecodeUTF16(b, true), true
+	case KindGzip:
+		out, err := Gunzip(b, int64(limit))
+		if err != nil && len(out) == 0 {
+			return "gzip", "decompression failed: " + err.Error(), true
+		}
+		inner := Sniff(out)
+		if inner == KindText {
+			return fmt.Sprintf("gunzipped (%d bytes, text)", len(out)), string(out), true
+		}
+		return fmt.Sprintf("gunzipped (%d bytes, %s)", len(out), inner), HexDump(out, limit), true
+	case KindText:
+		return "text", string(b), true
+	}
+	return "", "", false
+}
+
+// IsBinaryColumn reports whether a column's database type name denotes
+// raw bytes across the supported drivers.
+func IsBinaryColumn(dbType string) bool {
+	t := strings.ToUpper(dbType)
+	switch t {
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB",
+		"BINARY", "VARBINARY", "IMAGE", "BYTEA":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+// Package cellfmt turns result values into display text without losing
+// information: SQL NULL, the empty string, whitespace-only strings and
+// control characters all look different from each other and from
+// ordinary text. The TUI and the table printer style the text by Kind.
+package cellfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bgunnarsson/binsql/internal/blob"
+)
+
+// NullGlyph is shown for SQL NULL. The default is a symbol, not a word,
+// so it stays distinct from the string 'NULL' even where it cannot be
+// styled (dim, italic): without colour, or when piped.
+var NullGlyph = "∅"
+
+// EmptyGlyph is shown for the empty string.
+const EmptyGlyph = "''"
+
+type Kind int
+
+const (
+	Plain  Kind = iota
+	Null        // SQL NULL
+	Empty       // ""
+	Blank       // only whitespace, shown with visible markers
+	Binary      // non-text []byte, shown as a summary
+)
+
+// Text is the raw textual form of v, as used for sorting and filtering.
+// NULL gives "", and binary values longer than 16 bytes a summary, so
+// exports write those themselves.
+func Text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		if blob.IsText(t) {
+			return string(t)
+		}
+		return blob.Summary(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// Format returns single-line display text for v and how to style it.
+// Line breaks, tabs and other control characters are replaced by
+// visible symbols.
+func Format(v any) (string, Kind) {
+	if v == nil {
+		return NullGlyph, Null
+	}
+	if b, ok := v.([]byte); ok && !blob.IsText(b) {
+		return blob.Summary(b), Binary
+	}
+	s := Text(v)
+	switch {
+	case s == "":
+		return EmptyGlyph, Empty
+	case strings.TrimSpace(s) == "":
+		return visibleBlank(s), Blank
+	}
+	return Visible(s, false), Plain
+}
+
+// Visible replaces control characters with Unicode control pictures
+// (␀, ␛, …), tabs with → and, unless keepNewlines, line breaks with ↵.
+func Visible(s string, keepNewlines bool) string {
+	clean := true
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for i, r := range s {
+		switch {
+		case r == '\n' && keepNewlines:
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteRune('↵')
+		case r == '\r' && keepNewlines && i+1 < len(s) && s[i+1] == '\n':
+			// CRLF: keep the line break only
+		case r == '\t':
+			b.WriteRune('→')
+		case r < 0x20:
+			b.WriteRune(0x2400 + r) // ␀ … ␟
+		case r == 0x7f:
+			b.WriteRune('␡')
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, "\\u%04x", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// visibleBlank shows each whitespace character of an all-blank string.
+func visibleBlank(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case ' ':
+			b.WriteRune('·')
+		case '\t':
+			b.WriteRune('→')
+		case '\n':
+			b.WriteRune('↵')
+		case '\r':
+			b.WriteRune('␍')
+		default:
+			b.WriteRune('␣')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+// Package config reads the user's settings file, config.json in the
+// binsql config directory (see Dir).
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config is the contents of config.json. Every field is optional.
+type Config struct {
+	// Theme names a bundled theme (dark, light, ansi16, mono) or a file
+	// <name>.json in ThemeDir. Empty picks one from the terminal.
+	Theme string `json:"theme,omitempty"`
+
+	// Keys rebinds actions: action id -> key strings, e.g.
+	// "results.sort": ["S"]. Unlisted actions keep their defaults.
+	Keys map[string][]string `json:"keys,omitempty"`
+}
+
+// Dir is the binsql config directory, e.g. ~/.config/binsql; "" if the
+// platform has no user config directory.
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "binsql")
+}
+
+// ThemeDir is where user themes are looked up.
+func ThemeDir() string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "themes")
+}
+
+// Load reads config.json. A missing file is not an error and gives the
+// zero Config; a malformed one is.
+func Load() (*Config, error) {
+	c := &Config{}
+	dir := Dir()
+	if dir == "" {
+		return c, nil
+	}
+	path := filepath.Join(dir, "config.json")
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
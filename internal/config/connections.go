@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Connection is a saved connection, managed with "binsql conn" and used
+// by name (--conn) instead of a driver and DSN.
+type Connection struct {
+	Name   string `json:"name"`
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"`
+
+	// Credential names the password in the encrypted credentials file
+	// (see CredentialsPath) that is added to DSN when connecting.
+	Credential string `json:"credential,omitempty"`
+
+	// SSH is the jump host the database is reached through, if any.
+	SSH *SSH `json:"ssh,omitempty"`
+
+	// TLS replaces the DSN's own TLS parameters, if set.
+	TLS *TLS `json:"tls,omitempty"`
+
+	// ConnectTimeout and QueryTimeout override the defaults (5s, none);
+	// --connect-timeout and --query-timeout override them in turn.
+	ConnectTimeout Duration `json:"connect_timeout,omitempty"`
+	QueryTimeout   Duration `json:"query_timeout,omitempty"`
+
+	// Pool sizes the connection pool, if set.
+	Pool *Pool `json:"pool,omitempty"`
+}
+
+// SSH is an SSH jump host (see package sshtunnel).
+type SSH struct {
+	Host       string `json:"host"` // host or host:port
+	User       string `json:"user,omitempty"`
+	KeyFile    string `json:"key_file,omitempty"`
+	Agent      bool   `json:"agent,omitempty"`       // log in with ssh-agent
+	KnownHosts string `json:"known_hosts,omitempty"` // default ~/.ssh/known_hosts
+}
+
+// TLS is how a connection is encrypted, the same for every driver (see
+// db.TLS).
+type TLS struct {
+	Mode       string `json:"mode,omitempty"` // disable, prefer, require, verify-ca, verify-full
+	CAFile     string `json:"ca_file,omitempty"`
+	CertFile   string `json:"cert_file,omitempty"`
+	KeyFile    string `json:"key_file,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	SkipVerify bool   `json:"skip_verify,omitempty"`
+}
+
+// Pool is a connection's pool settings (see db.Pool). Zero fields keep
+// the defaults.
+type Pool struct {
+	MaxOpenConns    int      `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int      `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime,omitempty"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time,omitempty"`
+}
+
+// Duration is a time.Duration written as "30s" rather than in
+// nanoseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ConnectionsPath is the saved connections file. Unlike config.json it
+// is written by binsql.
+func ConnectionsPath() string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "connections.json")
+}
+
+// LoadConnections reads the saved connections, sorted by name. A missing
+// file means none.
+func LoadConnections() ([]Connection, error) {
+	path := ConnectionsPath()
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cs []Connection
+	if err := json.Unmarshal(b, &cs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Name < cs[j].Name })
+	return cs, nil
+}
+
+// FindConnection returns the connection called name.
+func FindConnection(cs []Connection, name string) (Connection, bool) {
+	for _, c := range cs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Connection{}, false
+}
+
+// SaveConnection adds c, replacing a connection of the same name. The
+// file is only readable by the user since DSNs may hold passwords.
+func SaveConnection(c Connection) error {
+	path := ConnectionsPath()
+	if path == "" {
+		return errors.New("no user config directory")
+	}
+	cs, err := LoadConnections()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range cs {
+		if cs[i].Name == c.Name {
+			cs[i], replaced = c, true
+		}
+	}
+	if !replaced {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Name < cs[j].Name })
+
+	b, err := json.MarshalIndent(cs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+)
+
+// CredentialsPath is the encrypted credentials file: a JSON object of
+// credential name -> password, encrypted with age under a passphrase.
+// Saved connections refer to their entry by name (Connection.Credential).
+func CredentialsPath() string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "credentials.age")
+}
+
+// HasCredentials reports whether the credentials file exists.
+func HasCredentials() bool {
+	path := CredentialsPath()
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// LoadCredentials decrypts the credentials file with passphrase. A
+// missing file means none.
+func LoadCredentials(passphrase string) (map[string]string, error) {
+	path := CredentialsPath()
+	if path == "" {
+		return map[string]string{}, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	id, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(f, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w (wrong passphrase?)", path, err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	creds := map[string]string{}
+	if err := json.Unmarshal(b, &creds); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// SaveCredentials encrypts creds with passphrase and replaces the
+// credentials file.
+func SaveCredentials(creds map[string]string, passphrase string) error {
+	path := CredentialsPath()
+	if path == "" {
+		return errors.New("no user config directory")
+	}
+	rcpt, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, rcpt)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// writeFileAtomic replaces path with b through a 0600 temp file in the
+// same directory, so a failed write leaves the old file as it was.
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // a no-op after the rename
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
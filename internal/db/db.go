@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Column struct {
@@ -23,3 +24,113 @@ type DB interface {
 	Query(ctx context.Context, sql string, args ...any) (*Rows, error)
 }
 
+// ConnInfo describes where a connection goes once its DSN has been
+// completed from the environment and the native clients' config files.
+// Fields the adapter cannot tell are left empty.
+type ConnInfo struct {
+	Host     string
+	Port     string
+	Database string
+	User     string
+	Password bool     // a password will be sent
+	Sources  []string // settings not from the DSN: "PGHOST", "~/.pgpass", …
+	TLS      *TLS     // the TLS the DSN asks for, by mode; nil for sqlite
+}
+
+// Notification is a message received on a pub/sub channel.
+type Notification struct {
+	Time    time.Time
+	Channel string
+	Payload string
+	PID     uint32 // sending backend, if the server reports it
+}
+
+// Notifier is implemented by adapters with server-side pub/sub
+// (postgres LISTEN/NOTIFY). The UI checks for it with a type assertion.
+type Notifier interface {
+	// Listen subscribes to channels on a dedicated connection and calls fn
+	// for every notification until ctx is cancelled.
+	Listen(ctx context.Context, channels []string, fn func(Notification)) error
+	Notify(ctx context.Context, channel, payload string) error
+}
+
+// PlanNode is one operator in a query plan, normalized across drivers.
+// Numbers the driver does not report are left at zero.
+type PlanNode struct {
+	Op         string  // operator, e.g. "Seq Scan", "Clustered Index Seek"
+	Target     string  // table/index the operator reads, if any
+	Detail     string  // filter, join condition or driver-specific extra
+	Cost       float64 // estimated total cost in driver units (includes children)
+	Rows       float64 // estimated rows
+	ActualMs   float64 // analyze only: total time including children
+	ActualRows float64
+	Loops      float64
+	FullScan   bool // sequential / full table scan
+	Children   []*PlanNode
+}
+
+// Explainer is implemented by adapters that can produce a query plan.
+// With analyze set the statement is executed inside a transaction that
+// is always rolled back.
+type Explainer interface {
+	Explain(ctx context.Context, query string, analyze bool) (*PlanNode, error)
+}
+
+// ServerInfo describes the server and the session binsql is connected
+// with. Fields the driver has no notion of are left empty.
+type ServerInfo struct {
+	Version      string
+	Database     string
+	Schema       string // current schema, where that differs from the database
+	User         string
+	Roles        []string
+	TimeZone     string
+	Encoding     string
+	Size         int64 // database size in bytes; -1 if unknown
+	ConnectionID string
+	Settings     []Setting // session settings: search_path, sql_mode, isolation level, …
+}
+
+// Setting is a named session setting.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// InfoReporter is implemented by adapters that can describe their
+// server and session. Parts the server will not tell (missing
+// privileges, older versions) are left empty rather than failing.
+type InfoReporter interface {
+	ServerInfo(ctx context.Context) (*ServerInfo, error)
+}
+
+// Pinger is implemented by adapters that talk to a server through a
+// connection pool. The health check uses it to tell whether the server
+// is still reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+	// ResetPool drops the idle connections, which may be dead after a
+	// network outage, so the next use dials a fresh one.
+	ResetPool()
+}
+
+// ReadOnlySetter is implemented by adapters that can have the server
+// refuse writes. With read-only on, Query runs each statement in a
+// transaction that is rolled back, read-only where the server has such
+// a mode, so writes hidden in functions fail or are undone. The UI sets
+// it alongside its own keyword check (see sqllex.IsSelect).
+type ReadOnlySetter interface {
+	SetReadOnly(on bool)
+}
+
+// Unwrap returns the innermost DB behind wrappers (such as the schema
+// cache) so optional interfaces like Explainer can be type-asserted.
+func Unwrap(d DB) DB {
+	for {
+		u, ok := d.(interface{ Unwrap() DB })
+		if !ok {
+			return d
+		}
+		d = u.Unwrap()
+	}
+}
@@ -0,0 +1,299 @@
+// Package health keeps an eye on the connection to the server: a
+// background ping tells whether it is still reachable and how fast, and
+// read-only queries that hit a broken connection are retried once on a
+// fresh one. It sits in front of a db.DB and implements db.DB itself.
+package health
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// pingTimeout bounds one health check.
+const pingTimeout = 5 * time.Second
+
+// downAfter is how many failed pings in a row turn "reconnecting" into
+// "disconnected".
+const downAfter = 3
+
+// State is what the last health check found.
+type State int
+
+const (
+	Connected    State = iota
+	Reconnecting       // the server stopped answering; retrying
+	Disconnected       // retried and gave up for now; still checking
+)
+
+func (s State) String() string {
+	switch s {
+	case Reconnecting:
+		return "reconnecting"
+	case Disconnected:
+		return "disconnected"
+	}
+	return "connected"
+}
+
+// Status is the result of the last health check.
+type Status struct {
+	State   State
+	Latency time.Duration // round trip of the last good ping
+	Err     error         // why the last ping or query failed, if it did
+	At      time.Time
+}
+
+type Options struct {
+	// Interval between pings while connected; zero disables the
+	// background check. After a failure binsql retries sooner.
+	Interval time.Duration
+	// IsConnError tells a broken connection from a failing query; nil
+	// uses IsConnError.
+	IsConnError func(error) bool
+	// Retryable reports whether a query is safe to run twice; nil
+	// retries nothing but ListTables and DescribeTable.
+	Retryable func(query string) bool
+}
+
+type DB struct {
+	inner  db.DB
+	pinger db.Pinger // nil when the adapter has no server to ping
+	opts   Options
+	kick   chan struct{}
+
+	mu       sync.Mutex
+	status   Status
+	failures int
+	onChange func()
+}
+
+// New wraps inner, which starts out connected.
+func New(inner db.DB, opts Options) *DB {
+	if opts.IsConnError == nil {
+		opts.IsConnError = IsConnError
+	}
+	h := &DB{
+		inner:  inner,
+		opts:   opts,
+		kick:   make(chan struct{}, 1),
+		status: Status{State: Connected, At: time.Now()},
+	}
+	h.pinger, _ = db.Unwrap(inner).(db.Pinger)
+	return h
+}
+
+// Watched reports whether Start has anything to ping.
+func (h *DB) Watched() bool { return h.pinger != nil && h.opts.Interval > 0 }
+
+// SetOnChange registers fn to be called (from a background goroutine)
+// when the status changes, and after every ping.
+func (h *DB) SetOnChange(fn func()) {
+	h.mu.Lock()
+	h.onChange = fn
+	h.mu.Unlock()
+}
+
+// Status returns the result of the last check.
+func (h *DB) Status() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// Start pings the server every Interval until ctx is done, and sooner
+// (backing off up to Interval) while it does not answer.
+func (h *DB) Start(ctx context.Context) {
+	if !h.Watched() {
+		return
+	}
+	go func() {
+		t := time.NewTimer(0)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			case <-h.kick:
+				if !t.Stop() {
+					select {
+					case <-t.C:
+					default:
+					}
+				}
+			}
+			h.check(ctx)
+			t.Reset(h.nextCheck())
+		}
+	}()
+}
+
+// Reconnect drops the idle connections and has the background check
+// look at the server now rather than at the next ping.
+func (h *DB) Reconnect() {
+	if !h.Watched() {
+		return
+	}
+	h.pinger.ResetPool()
+	h.set(func(s *Status) { s.State = Reconnecting })
+	h.checkNow()
+}
+
+// nextCheck is how long to wait before the next ping: Interval while
+// connected, else 1s doubling per failure up to Interval.
+func (h *DB) nextCheck() time.Duration {
+	h.mu.Lock()
+	n := h.failures
+	h.mu.Unlock()
+	if n == 0 {
+		return h.opts.Interval
+	}
+	d := time.Second << min(n-1, 10)
+	return min(d, h.opts.Interval)
+}
+
+func (h *DB) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	err := h.pinger.Ping(ctx)
+	latency := time.Since(start)
+	if err != nil && ctx.Err() == context.Canceled {
+		return // shutting down
+	}
+
+	h.mu.Lock()
+	if err == nil {
+		h.failures = 0
+	} else {
+		h.failures++
+	}
+	failures := h.failures
+	h.mu.Unlock()
+
+	if failures == 1 {
+		// Idle connections from before an outage are likely dead too.
+		h.pinger.ResetPool()
+	}
+	h.set(func(s *Status) {
+		switch {
+		case err == nil:
+			s.State, s.Latency, s.Err = Connected, latency, nil
+		case failures >= downAfter:
+			s.State, s.Err = Disconnected, err
+		default:
+			s.State, s.Err = Reconnecting, err
+		}
+	})
+}
+
+// set updates the status and reports the change.
+func (h *DB) set(fn func(*Status)) {
+	h.mu.Lock()
+	fn(&h.status)
+	h.status.At = time.Now()
+	onChange := h.onChange
+	h.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// broken notes a query that lost its connection and has the background
+// check look at the server now.
+func (h *DB) broken(err error) {
+	if h.pinger == nil {
+		return
+	}
+	h.set(func(s *Status) { s.State, s.Err = Reconnecting, err })
+	h.checkNow()
+}
+
+// checkNow wakes the background check.
+func (h *DB) checkNow() {
+	select {
+	case h.kick <- struct{}{}:
+	default:
+	}
+}
+
+// retry runs fn, and once more on a fresh connection when it failed on
+// a broken one and retry is set.
+func (h *DB) retry(ctx context.Context, retry bool, fn func() error) error {
+	err := fn()
+	if err == nil || ctx.Err() != nil || !h.opts.IsConnError(err) {
+		return err
+	}
+	h.broken(err)
+	if !retry {
+		return err
+	}
+	if h.pinger != nil {
+		h.pinger.ResetPool()
+	}
+	return fn()
+}
+
+func (h *DB) ListTables(ctx context.Context) ([]string, error) {
+	var tables []string
+	err := h.retry(ctx, true, func() (err error) {
+		tables, err = h.inner.ListTables(ctx)
+		return err
+	})
+	return tables, err
+}
+
+func (h *DB) DescribeTable(ctx context.Context, table string) ([]db.Column, error) {
+	var cols []db.Column
+	err := h.retry(ctx, true, func() (err error) {
+		cols, err = h.inner.DescribeTable(ctx, table)
+		return err
+	})
+	return cols, err
+}
+
+func (h *DB) Query(ctx context.Context, sql string, args ...any) (*db.Rows, error) {
+	var rows *db.Rows
+	retry := h.opts.Retryable != nil && h.opts.Retryable(sql)
+	err := h.retry(ctx, retry, func() (err error) {
+		rows, err = h.inner.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (h *DB) Close() error { return h.inner.Close() }
+
+// Unwrap exposes the adapter for optional interfaces (see db.Unwrap).
+func (h *DB) Unwrap() db.DB { return h.inner }
+
+// IsConnError reports whether err means the connection itself failed
+// (reset, closed, unreachable) rather than the statement.
+func IsConnError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch {
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, net.ErrClosed),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH):
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
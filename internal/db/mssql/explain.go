@@ -0,0 +1,223 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Explain returns the estimated plan via SET SHOWPLAN_XML, or the actual
+// plan via SET STATISTICS XML when analyze is set. Both SET options are
+// per-session, so everything runs on one pinned connection.
+func (m *MssqlDB) Explain(ctx context.Context, query string, analyze bool) (*db.PlanNode, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var planXML string
+	if analyze {
+		planXML, err = actualPlanXML(ctx, conn, query)
+	} else {
+		planXML, err = estimatedPlanXML(ctx, conn, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if planXML == "" {
+		return nil, fmt.Errorf("server returned no plan")
+	}
+
+	var doc xmlElem
+	if err := xml.Unmarshal([]byte(planXML), &doc); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+
+	root := &db.PlanNode{Op: "batch"}
+	for _, stmt := range doc.findAll("StmtSimple") {
+		n := &db.PlanNode{
+			Op:     "statement",
+			Detail: strings.TrimSpace(stmt.attr("StatementText")),
+			Cost:   stmt.floatAttr("StatementSubTreeCost"),
+			Rows:   stmt.floatAttr("StatementEstRows"),
+		}
+		for _, rel := range stmt.relOps() {
+			n.Children = append(n.Children, relOpNode(rel))
+		}
+		root.Children = append(root.Children, n)
+	}
+	if len(root.Children) == 1 {
+		return root.Children[0], nil
+	}
+	return root, nil
+}
+
+func estimatedPlanXML(ctx context.Context, conn *sql.Conn, query string) (string, error) {
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return "", err
+	}
+	defer conn.ExecContext(context.Background(), "SET SHOWPLAN_XML OFF")
+
+	// With SHOWPLAN on, the statement is compiled, not executed.
+	var out string
+	if err := conn.QueryRowContext(ctx, query).Scan(&out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func actualPlanXML(ctx context.Context, conn *sql.Conn, query string) (string, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SET STATISTICS XML ON"); err != nil {
+		return "", err
+	}
+	defer tx.ExecContext(context.Background(), "SET STATISTICS XML OFF")
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	// The query's own result sets come first; the plan is a trailing
+	// single-column result set of showplan XML.
+	var plan string
+	for {
+		cols, _ := rows.Columns()
+		isPlan := len(cols) == 1 && strings.Contains(cols[0], "Showplan")
+		for rows.Next() {
+			if !isPlan {
+				continue
+			}
+			var s string
+			if err := rows.Scan(&s); err != nil {
+				return "", err
+			}
+			plan = s
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	return plan, rows.Err()
+}
+
+// xmlElem is a generic XML tree; showplan is too large to map by hand.
+type xmlElem struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xmlElem  `xml:",any"`
+}
+
+func (e *xmlElem) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (e *xmlElem) floatAttr(name string) float64 {
+	f, _ := strconv.ParseFloat(e.attr(name), 64)
+	return f
+}
+
+func (e *xmlElem) findAll(local string) []*xmlElem {
+	var out []*xmlElem
+	for i := range e.Children {
+		c := &e.Children[i]
+		if c.XMLName.Local == local {
+			out = append(out, c)
+			continue
+		}
+		out = append(out, c.findAll(local)...)
+	}
+	return out
+}
+
+// relOps returns the nearest RelOp descendants, not crossing into them.
+func (e *xmlElem) relOps() []*xmlElem {
+	return e.findAll("RelOp")
+}
+
+// findShallow searches descendants for local without entering nested RelOps.
+func (e *xmlElem) findShallow(local string) *xmlElem {
+	for i := range e.Children {
+		c := &e.Children[i]
+		if c.XMLName.Local == "RelOp" {
+			continue
+		}
+		if c.XMLName.Local == local {
+			return c
+		}
+		if f := c.findShallow(local); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func relOpNode(rel *xmlElem) *db.PlanNode {
+	op := rel.attr("PhysicalOp")
+	if logical := rel.attr("LogicalOp"); logical != "" && logical != op {
+		op += " (" + logical + ")"
+	}
+	n := &db.PlanNode{
+		Op:   op,
+		Cost: rel.floatAttr("EstimatedTotalSubtreeCost"),
+		Rows: rel.floatAttr("EstimateRows"),
+	}
+	switch rel.attr("PhysicalOp") {
+	case "Table Scan", "Clustered Index Scan", "Index Scan":
+		n.FullScan = true
+	}
+
+	if obj := rel.findShallow("Object"); obj != nil {
+		var parts []string
+		for _, a := range []string{"Schema", "Table"} {
+			if v := obj.attr(a); v != "" {
+				parts = append(parts, strings.Trim(v, "[]"))
+			}
+		}
+		n.Target = strings.Join(parts, ".")
+		if idx := obj.attr("Index"); idx != "" {
+			n.Target += " using " + strings.Trim(idx, "[]")
+		}
+	}
+
+	if rt := rel.findShallow("RunTimeInformation"); rt != nil {
+		for _, t := range rt.findAll("RunTimeCountersPerThread") {
+			n.ActualRows += t.floatAttr("ActualRows")
+			n.Loops += t.floatAttr("ActualExecutions")
+			if ms := t.floatAttr("ActualElapsedms"); ms > n.ActualMs {
+				n.ActualMs = ms
+			}
+		}
+	}
+
+	for i := range rel.Children {
+		for _, child := range rel.Children[i].relOpsOrSelf() {
+			n.Children = append(n.Children, relOpNode(child))
+		}
+	}
+	return n
+}
+
+func (e *xmlElem) relOpsOrSelf() []*xmlElem {
+	if e.XMLName.Local == "RelOp" {
+		return []*xmlElem{e}
+	}
+	return e.relOps()
+}
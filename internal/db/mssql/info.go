@@ -0,0 +1,101 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// isolationLevels names sys.dm_exec_sessions.transaction_isolation_level.
+var isolationLevels = map[int64]string{
+	0: "unspecified",
+	1: "read uncommitted",
+	2: "read committed",
+	3: "repeatable read",
+	4: "serializable",
+	5: "snapshot",
+}
+
+// ServerInfo reports the server and one pooled session (db.InfoReporter).
+func (m *MssqlDB) ServerInfo(ctx context.Context) (*db.ServerInfo, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// DATENAME(TZOFFSET, …) works on every version; CURRENT_TIMEZONE()
+	// only from SQL Server 2019.
+	const q = `
+SELECT @@VERSION, DB_NAME(), COALESCE(SCHEMA_NAME(), ''), SUSER_SNAME(), COALESCE(USER_NAME(), ''),
+       DATENAME(TZOFFSET, SYSDATETIMEOFFSET()),
+       CAST(DATABASEPROPERTYEX(DB_NAME(), 'Collation') AS nvarchar(128)),
+       @@LANGUAGE, @@LOCK_TIMEOUT, @@SPID`
+	info := &db.ServerInfo{Size: -1}
+	var dbUser, language string
+	var lockTimeout, spid int64
+	if err := conn.QueryRowContext(ctx, q).Scan(&info.Version, &info.Database, &info.Schema, &info.User, &dbUser,
+		&info.TimeZone, &info.Encoding, &language, &lockTimeout, &spid); err != nil {
+		return nil, err
+	}
+	info.ConnectionID = strconv.FormatInt(spid, 10)
+	if dbUser != "" && dbUser != info.User {
+		info.User += " (database user " + dbUser + ")"
+	}
+	var tzName string
+	if conn.QueryRowContext(ctx, "SELECT CURRENT_TIMEZONE()").Scan(&tzName) == nil {
+		info.TimeZone = tzName
+	}
+	info.Settings = []db.Setting{
+		{Name: "language", Value: language},
+		{Name: "lock timeout", Value: strconv.FormatInt(lockTimeout, 10) + " ms"},
+	}
+
+	// A session can always see its own row.
+	var level sql.NullInt64
+	var dateFormat sql.NullString
+	err = conn.QueryRowContext(ctx, `
+SELECT transaction_isolation_level, date_format FROM sys.dm_exec_sessions
+WHERE session_id = @@SPID`).Scan(&level, &dateFormat)
+	if err == nil {
+		if level.Valid {
+			info.Settings = append(info.Settings, db.Setting{Name: "isolation level", Value: isolationLevels[level.Int64]})
+		}
+		if dateFormat.Valid {
+			info.Settings = append(info.Settings, db.Setting{Name: "date format", Value: dateFormat.String})
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+SELECT name FROM sys.database_principals
+WHERE type = 'R' AND name <> 'public' AND IS_MEMBER(name) = 1
+ORDER BY name`)
+	if err == nil {
+		for rows.Next() {
+			var r string
+			if rows.Scan(&r) == nil {
+				info.Roles = append(info.Roles, r)
+			}
+		}
+		rows.Close()
+	}
+	if isSysadmin(ctx, conn) {
+		info.Roles = append([]string{"sysadmin"}, info.Roles...)
+	}
+
+	var size sql.NullInt64
+	if conn.QueryRowContext(ctx, `
+SELECT CAST(SUM(CAST(size AS bigint)) * 8192 AS bigint) FROM sys.database_files`).Scan(&size) == nil && size.Valid {
+		info.Size = size.Int64
+	}
+	return info, nil
+}
+
+// isSysadmin reports whether the login has the sysadmin server role.
+func isSysadmin(ctx context.Context, conn *sql.Conn) bool {
+	var member sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT IS_SRVROLEMEMBER('sysadmin')").Scan(&member)
+	return err == nil && member.Valid && member.Int64 == 1
+}
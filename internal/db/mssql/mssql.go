@@ -7,48 +7,70 @@ import (
 	"strings"
 	"time"
 
+	mssql "github.com/microsoft/go-mssqldb"
 	"github.com/microsoft/go-mssqldb/azuread"
-	_ "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
 
 	"github.com/bgunnarsson/binsql/internal/db"
 )
 
 type MssqlDB struct {
-	db *sql.DB
+	db   *sql.DB
+	opts db.Options // for ResetPool
+	ro   db.ReadOnly
 }
 
 // Open opens a MSSQL connection.
 // If the DSN contains "fedauth=", we use the Azure AD driver (azuresql)
 // so things like ActiveDirectoryInteractive / AzCli work.
-func Open(dsn string) (*MssqlDB, error) {
+// A non-nil o.TLS replaces the DSN's encrypt settings. SQL Server has no
+// session statement timeout: o.QueryTimeout becomes each session's
+// LOCK_TIMEOUT, and the query context's deadline cancels a query on the
+// server.
+func Open(dsn string, o db.Options) (*MssqlDB, error) {
 	if dsn == "" {
 		return nil, fmt.Errorf("empty mssql DSN")
 	}
 
-	driverName := "sqlserver"
-	if strings.Contains(strings.ToLower(dsn), "fedauth=") {
-		driverName = azuread.DriverName // "azuresql"
+	if o.TLS == nil && o.ServerName != "" {
+		dsn = withHostInCertificate(dsn, o.ServerName)
 	}
 
-	sqldb, err := sql.Open(driverName, dsn)
-	if err != nil {
-		return nil, err
+	var connector *mssql.Connector
+	if strings.Contains(strings.ToLower(dsn), "fedauth=") {
+		// The azuresql connector only takes a DSN.
+		if o.TLS != nil {
+			return nil, fmt.Errorf("TLS settings do not work with fedauth; use encrypt= in the DSN")
+		}
+		var err error
+		if connector, err = azuread.NewConnector(dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		cfg, err := msdsn.Parse(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if o.TLS != nil {
+			if err := applyTLS(&cfg, o.TLS); err != nil {
+				return nil, err
+			}
+		}
+		if o.ConnectTimeout > 0 {
+			cfg.DialTimeout = o.ConnectTimeout
+			cfg.ConnTimeout = o.ConnectTimeout
+		}
+		connector = mssql.NewConnectorConfig(cfg)
 	}
-
-	// small CLI defaults
-	sqldb.SetMaxOpenConns(4)
-	sqldb.SetMaxIdleConns(4)
-	sqldb.SetConnMaxLifetime(5 * time.Minute)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := sqldb.PingContext(ctx); err != nil {
-		sqldb.Close()
+	if o.QueryTimeout > 0 {
+		connector.SessionInitSQL = lockTimeout(o.QueryTimeout)
+	}
+	sqldb := sql.OpenDB(connector)
+	if err := o.Setup(sqldb); err != nil {
 		return nil, err
 	}
 
-	return &MssqlDB{db: sqldb}, nil
+	return &MssqlDB{db: sqldb, opts: o}, nil
 }
 
 // --- db.DB implementation ---
@@ -60,6 +82,16 @@ func (m *MssqlDB) Close() error {
 	return m.db.Close()
 }
 
+// Ping checks that the server can still be reached (db.Pinger).
+func (m *MssqlDB) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// ResetPool drops idle connections (db.Pinger).
+func (m *MssqlDB) ResetPool() {
+	m.opts.ResetPool(m.db)
+}
+
 func (m *MssqlDB) ListTables(ctx context.Context) ([]string, error) {
 	const q = `
 SELECT TABLE_SCHEMA + '.' + TABLE_NAME AS name
@@ -126,8 +158,18 @@ ORDER BY ORDINAL_POSITION;
 	return cols, nil
 }
 
+// SetReadOnly has Query run in a transaction that is rolled back (see
+// db.ReadOnlySetter). SQL Server has no read-only transactions.
+func (m *MssqlDB) SetReadOnly(on bool) { m.ro.SetReadOnly(on) }
+
 func (m *MssqlDB) Query(ctx context.Context, sqlQuery string, args ...any) (*db.Rows, error) {
-    rows, err := m.db.QueryContext(ctx, sqlQuery, args...)
+    q, done, err := m.ro.Begin(ctx, m.db, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer done()
+
+    rows, err := q.QueryContext(ctx, sqlQuery, args...)
     if err != nil {
         return nil, err
     }
@@ -180,8 +222,8 @@ func (m *MssqlDB) Query(ctx context.Context, sqlQuery string, args ...any) (*db.
                 case "uniqueidentifier":
                     values[i] = formatUniqueIdentifier(x)
                 default:
-                    // safe hex representation for any other binary
-                    values[i] = fmt.Sprintf("0x%x", x)
+                    // keep raw bytes; the printer/UI render them via internal/blob
+                    values[i] = x
                 }
 
             case time.Time:
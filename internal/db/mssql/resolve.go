@@ -0,0 +1,105 @@
+package mssql
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Resolve describes dsn. SQL Server clients have no environment or
+// config file conventions, so the DSN is returned unchanged.
+func Resolve(dsn string) (string, db.ConnInfo, error) {
+	cfg, err := msdsn.Parse(dsn)
+	if err != nil {
+		return "", db.ConnInfo{}, err
+	}
+	info := db.ConnInfo{
+		Host:     cfg.Host,
+		Database: cfg.Database,
+		User:     cfg.User,
+		Password: cfg.Password != "",
+		TLS:      dsnTLS(cfg),
+	}
+	if cfg.Instance != "" {
+		info.Host += `\` + cfg.Instance
+	}
+	if cfg.Port != 0 {
+		info.Port = strconv.FormatUint(cfg.Port, 10)
+	}
+	return dsn, info, nil
+}
+
+// dsnTLS is the TLS mode cfg's encrypt and TrustServerCertificate
+// settings amount to. encrypt=false (the default) encrypts the login
+// and then only what the server insists on.
+func dsnTLS(cfg msdsn.Config) *db.TLS {
+	switch {
+	case cfg.Encryption == msdsn.EncryptionDisabled || cfg.TLSConfig == nil:
+		return &db.TLS{Mode: db.TLSDisable}
+	case cfg.Encryption == msdsn.EncryptionOff:
+		return &db.TLS{Mode: db.TLSPrefer}
+	case cfg.TLSConfig.InsecureSkipVerify:
+		return &db.TLS{Mode: db.TLSRequire}
+	}
+	return &db.TLS{Mode: db.TLSVerifyFull}
+}
+
+// WithPassword sets the password of dsn, a sqlserver:// URL, an
+// "odbc:" string or an ADO key=value string.
+func WithPassword(dsn, password string) (string, error) {
+	switch {
+	case strings.HasPrefix(dsn, "sqlserver://"):
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String(), nil
+	case strings.HasPrefix(dsn, "odbc:"):
+		return strings.TrimSuffix(dsn, ";") + ";password={" + strings.ReplaceAll(password, "}", "}}") + "}", nil
+	}
+	return strings.TrimSuffix(dsn, ";") + `;password="` + strings.ReplaceAll(password, `"`, `""`) + `"`, nil
+}
+
+// WithAddress points dsn at host:port, dropping any instance name.
+func WithAddress(dsn, host, port string) (string, error) {
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Host, u.Path = net.JoinHostPort(host, port), ""
+		return u.String(), nil
+	}
+	// Later keys win, in both the ADO and the ODBC form.
+	return strings.TrimSuffix(dsn, ";") + ";server=" + host + ";port=" + port, nil
+}
+
+// withHostInCertificate has the encryption dsn asks for check the
+// certificate against name, unless dsn already says what to check.
+func withHostInCertificate(dsn, name string) string {
+	if strings.Contains(strings.ToLower(dsn), "hostnameincertificate=") {
+		return dsn
+	}
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + "hostNameInCertificate=" + url.QueryEscape(name)
+	}
+	return strings.TrimSuffix(dsn, ";") + ";hostNameInCertificate=" + name
+}
+
+// IsAuthError reports whether err is the server rejecting the login.
+func IsAuthError(err error) bool {
+	var msErr mssql.Error
+	return errors.As(err, &msErr) && msErr.Number == 18456 // login failed
+}
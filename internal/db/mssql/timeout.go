@@ -0,0 +1,24 @@
+package mssql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// lockTimeout is the session setup that bounds how long a statement
+// waits for locks. SQL Server has no statement timeout setting; the
+// query context's deadline sends the driver's cancel (an attention
+// packet) for everything else.
+func lockTimeout(d time.Duration) string {
+	return fmt.Sprintf("SET LOCK_TIMEOUT %d", d.Milliseconds())
+}
+
+// IsTimeoutError reports whether err is the server giving up on a lock
+// wait that ran past LOCK_TIMEOUT.
+func IsTimeoutError(err error) bool {
+	var msErr mssql.Error
+	return errors.As(err, &msErr) && msErr.Number == 1222 // lock request time out period exceeded
+}
@@ -0,0 +1,50 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// applyTLS replaces the encryption settings the DSN gave with t.
+// SQL Server has no client certificate logins, so those are refused.
+func applyTLS(cfg *msdsn.Config, t *db.TLS) error {
+	if t.CertFile != "" {
+		return errors.New("SQL Server does not take TLS client certificates")
+	}
+	if t.Disabled() {
+		cfg.Encryption, cfg.TLSConfig = msdsn.EncryptionDisabled, nil
+		return nil
+	}
+	tc, err := t.Config(cfg.Host)
+	if err != nil {
+		return err
+	}
+	// As msdsn.SetupTLS: SQL Server expects one TDS packet per record.
+	tc.DynamicRecordSizingDisabled = true
+	cfg.TLSConfig = tc
+	cfg.Encryption = msdsn.EncryptionRequired
+	if t.Optional() {
+		cfg.Encryption = msdsn.EncryptionOff // only the login is encrypted if the server declines
+	}
+	return nil
+}
+
+// TLSInfo asks the server whether the session is encrypted; it does not
+// report the protocol version or cipher.
+func (m *MssqlDB) TLSInfo(ctx context.Context) (db.TLSInfo, error) {
+	var encrypted sql.NullString
+	err := m.db.QueryRowContext(ctx,
+		"SELECT encrypt_option FROM sys.dm_exec_connections WHERE session_id = @@SPID").Scan(&encrypted)
+	if err != nil {
+		return db.TLSInfo{}, err
+	}
+	if encrypted.String == "TRUE" {
+		return db.TLSInfo{Version: "encrypted"}, nil
+	}
+	return db.TLSInfo{}, nil
+}
@@ -0,0 +1,246 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Explain uses EXPLAIN FORMAT=JSON for estimates. MySQL only reports
+// actual timings through EXPLAIN ANALYZE (8.0.18+), which prints a text
+// tree, so analyze mode parses that instead.
+func (m *MysqlDB) Explain(ctx context.Context, query string, analyze bool) (*db.PlanNode, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	// Roll back whatever the statement did, even for plain EXPLAIN.
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: m.ro.ReadOnlyOn()})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if analyze {
+		var raw string
+		if err := tx.QueryRowContext(ctx, "EXPLAIN ANALYZE "+query).Scan(&raw); err != nil {
+			return nil, err
+		}
+		return parseAnalyzeTree(raw)
+	}
+
+	var raw string
+	if err := tx.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query).Scan(&raw); err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	qb, ok := doc["query_block"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("plan has no query_block")
+	}
+	return queryBlockNode(qb), nil
+}
+
+func queryBlockNode(qb map[string]any) *db.PlanNode {
+	n := &db.PlanNode{Op: "query_block"}
+	if id, ok := qb["select_id"].(float64); ok {
+		n.Op = fmt.Sprintf("query_block #%d", int(id))
+	}
+	if ci, ok := qb["cost_info"].(map[string]any); ok {
+		n.Cost = jsonFloat(ci["query_cost"])
+	}
+	if msg, ok := qb["message"].(string); ok {
+		n.Detail = msg
+	}
+	n.Children = planChildren(qb)
+	return n
+}
+
+// planChildren walks the operator keys MySQL nests inside a block.
+func planChildren(obj map[string]any) []*db.PlanNode {
+	var out []*db.PlanNode
+
+	wrap := func(op string, sub map[string]any, detail string) {
+		n := &db.PlanNode{Op: op, Detail: detail, Children: planChildren(sub)}
+		if ci, ok := sub["cost_info"].(map[string]any); ok {
+			n.Cost = jsonFloat(ci["sort_cost"])
+		}
+		out = append(out, n)
+	}
+
+	if t, ok := obj["table"].(map[string]any); ok {
+		out = append(out, tableNode(t))
+	}
+	if loop, ok := obj["nested_loop"].([]any); ok {
+		n := &db.PlanNode{Op: "nested loop"}
+		for _, item := range loop {
+			if m, ok := item.(map[string]any); ok {
+				n.Children = append(n.Children, planChildren(m)...)
+			}
+		}
+		out = append(out, n)
+	}
+	if sub, ok := obj["ordering_operation"].(map[string]any); ok {
+		detail := ""
+		if fs, _ := sub["using_filesort"].(bool); fs {
+			detail = "using filesort"
+		}
+		wrap("ORDER BY", sub, detail)
+	}
+	if sub, ok := obj["grouping_operation"].(map[string]any); ok {
+		detail := ""
+		if tmp, _ := sub["using_temporary_table"].(bool); tmp {
+			detail = "using temporary table"
+		}
+		wrap("GROUP BY", sub, detail)
+	}
+	if sub, ok := obj["duplicates_removal"].(map[string]any); ok {
+		wrap("DISTINCT", sub, "")
+	}
+	if sub, ok := obj["windowing"].(map[string]any); ok {
+		wrap("WINDOW", sub, "")
+	}
+	if sub, ok := obj["union_result"].(map[string]any); ok {
+		n := &db.PlanNode{Op: "UNION"}
+		if specs, ok := sub["query_specifications"].([]any); ok {
+			for _, spec := range specs {
+				if sm, ok := spec.(map[string]any); ok {
+					if qb, ok := sm["query_block"].(map[string]any); ok {
+						n.Children = append(n.Children, queryBlockNode(qb))
+					}
+				}
+			}
+		}
+		out = append(out, n)
+	}
+	for _, key := range []string{"attached_subqueries", "optimized_away_subqueries", "order_by_subqueries", "group_by_subqueries"} {
+		subs, ok := obj[key].([]any)
+		if !ok {
+			continue
+		}
+		for _, sub := range subs {
+			if sm, ok := sub.(map[string]any); ok {
+				if qb, ok := sm["query_block"].(map[string]any); ok {
+					out = append(out, queryBlockNode(qb))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func tableNode(t map[string]any) *db.PlanNode {
+	access, _ := t["access_type"].(string)
+	name, _ := t["table_name"].(string)
+	n := &db.PlanNode{
+		Op:       "table " + access,
+		Target:   name,
+		Rows:     jsonFloat(t["rows_examined_per_scan"]),
+		FullScan: access == "ALL",
+	}
+	if key, ok := t["key"].(string); ok {
+		n.Target += " using " + key
+	}
+	if ci, ok := t["cost_info"].(map[string]any); ok {
+		n.Cost = jsonFloat(ci["prefix_cost"])
+	}
+	if cond, ok := t["attached_condition"].(string); ok {
+		n.Detail = cond
+	}
+	if sub, ok := t["materialized_from_subquery"].(map[string]any); ok {
+		if qb, ok := sub["query_block"].(map[string]any); ok {
+			n.Children = append(n.Children, queryBlockNode(qb))
+		}
+	}
+	n.Children = append(n.Children, planChildren(withoutTable(t))...)
+	return n
+}
+
+// withoutTable avoids recursing into the table object itself.
+func withoutTable(t map[string]any) map[string]any {
+	out := make(map[string]any, len(t))
+	for k, v := range t {
+		if k != "table" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// MySQL prints most numbers in plan JSON as strings.
+func jsonFloat(v any) float64 {
+	switch x := v.(type) {
+	case float64:
+		return x
+	case string:
+		f, _ := strconv.ParseFloat(x, 64)
+		return f
+	}
+	return 0
+}
+
+var (
+	analyzeEst    = regexp.MustCompile(`\(cost=([\d.e+]+)(?:\.\.[\d.e+]+)? rows=([\d.e+]+)\)`)
+	analyzeActual = regexp.MustCompile(`\(actual time=[\d.e+]+\.\.([\d.e+]+) rows=([\d.e+]+) loops=(\d+)\)`)
+)
+
+// parseAnalyzeTree parses EXPLAIN ANALYZE output, where each operator is a
+// "-> ..." line indented four spaces per level.
+func parseAnalyzeTree(raw string) (*db.PlanNode, error) {
+	root := &db.PlanNode{Op: "query"}
+	stack := []*db.PlanNode{root}
+	depths := []int{-1}
+
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "-> ") {
+			continue
+		}
+		depth := len(line) - len(trimmed)
+		text := strings.TrimPrefix(trimmed, "-> ")
+
+		n := &db.PlanNode{}
+		op := text
+		if i := strings.Index(text, "  ("); i >= 0 {
+			op = text[:i]
+		}
+		n.Op = op
+		n.FullScan = strings.HasPrefix(op, "Table scan")
+		if mt := analyzeEst.FindStringSubmatch(text); mt != nil {
+			n.Cost, _ = strconv.ParseFloat(mt[1], 64)
+			n.Rows, _ = strconv.ParseFloat(mt[2], 64)
+		}
+		if mt := analyzeActual.FindStringSubmatch(text); mt != nil {
+			perLoop, _ := strconv.ParseFloat(mt[1], 64)
+			rows, _ := strconv.ParseFloat(mt[2], 64)
+			loops, _ := strconv.ParseFloat(mt[3], 64)
+			n.ActualMs = perLoop * loops
+			n.ActualRows = rows * loops
+			n.Loops = loops
+		}
+
+		for len(depths) > 1 && depths[len(depths)-1] >= depth {
+			stack = stack[:len(stack)-1]
+			depths = depths[:len(depths)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, n)
+		stack = append(stack, n)
+		depths = append(depths, depth)
+	}
+
+	if len(root.Children) == 0 {
+		return nil, fmt.Errorf("could not parse EXPLAIN ANALYZE output")
+	}
+	if len(root.Children) == 1 {
+		return root.Children[0], nil
+	}
+	return root, nil
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// ServerInfo reports the server and one pooled session (db.InfoReporter).
+func (m *MysqlDB) ServerInfo(ctx context.Context) (*db.ServerInfo, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	const q = `
+SELECT VERSION(), COALESCE(DATABASE(), ''), CURRENT_USER(), @@time_zone, @@system_time_zone,
+       @@character_set_server, @@character_set_connection, @@sql_mode, CONNECTION_ID()`
+	info := &db.ServerInfo{Size: -1}
+	var tz, systemTZ, connCharset, sqlMode string
+	var id int64
+	if err := conn.QueryRowContext(ctx, q).Scan(&info.Version, &info.Database, &info.User, &tz, &systemTZ,
+		&info.Encoding, &connCharset, &sqlMode, &id); err != nil {
+		return nil, err
+	}
+	info.TimeZone = tz
+	if tz == "SYSTEM" {
+		info.TimeZone = "SYSTEM (" + systemTZ + ")"
+	}
+	info.ConnectionID = strconv.FormatInt(id, 10)
+	info.Settings = []db.Setting{
+		{Name: "sql_mode", Value: sqlMode},
+		{Name: "character_set_connection", Value: connCharset},
+	}
+
+	// transaction_isolation replaced tx_isolation in MySQL 8 and MariaDB
+	// 11.1; max_execution_time is MySQL's, max_statement_time MariaDB's.
+	for _, vars := range [][]string{
+		{"transaction_isolation", "tx_isolation"},
+		{"autocommit"},
+		{"max_execution_time", "max_statement_time"},
+		{"lock_wait_timeout"},
+	} {
+		for _, name := range vars {
+			var v sql.NullString
+			if conn.QueryRowContext(ctx, "SELECT @@SESSION."+name).Scan(&v) == nil {
+				info.Settings = append(info.Settings, db.Setting{Name: name, Value: v.String})
+				break
+			}
+		}
+	}
+
+	// Active roles; CURRENT_ROLE() is MySQL 8 and MariaDB 10.0.5+.
+	var roles sql.NullString
+	if conn.QueryRowContext(ctx, "SELECT CURRENT_ROLE()").Scan(&roles) == nil &&
+		roles.Valid && roles.String != "NONE" && roles.String != "" {
+		for _, r := range strings.Split(roles.String, ",") {
+			info.Roles = append(info.Roles, strings.TrimSpace(r))
+		}
+	}
+
+	if info.Database != "" {
+		var size sql.NullInt64
+		err := conn.QueryRowContext(ctx, `
+SELECT SUM(data_length + index_length) FROM information_schema.tables
+WHERE table_schema = DATABASE()`).Scan(&size)
+		if err == nil && size.Valid {
+			info.Size = size.Int64
+		}
+	}
+	return info, nil
+}
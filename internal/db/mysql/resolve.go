@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Resolve completes dsn the way the mysql client does: settings missing
+// from the DSN come from the [client] section of the option files
+// (/etc/my.cnf, /etc/mysql/my.cnf, ~/.my.cnf, later ones winning) and
+// then from MYSQL_HOST, MYSQL_TCP_PORT, MYSQL_UNIX_PORT, MYSQL_PWD and
+// USER. dsn may be empty or a mysql:// URL. It returns the driver DSN.
+func Resolve(dsn string) (string, db.ConnInfo, error) {
+	if strings.HasPrefix(dsn, "mysql://") {
+		var err error
+		if dsn, err = fromURL(dsn); err != nil {
+			return "", db.ConnInfo{}, err
+		}
+	}
+	cfg := mysql.NewConfig()
+	if dsn != "" {
+		var err error
+		if cfg, err = mysql.ParseDSN(dsn); err != nil {
+			return "", db.ConnInfo{}, err
+		}
+	}
+
+	opts, files := optionFiles()
+	var sources []string
+	lookup := func(key, env string) string {
+		if v, ok := opts[key]; ok {
+			sources = appendOnce(sources, files[key])
+			return v
+		}
+		if v := os.Getenv(env); env != "" && v != "" {
+			sources = appendOnce(sources, env)
+			return v
+		}
+		return ""
+	}
+
+	if cfg.User == "" {
+		cfg.User = lookup("user", "USER") // the login name, as the client does
+	}
+	if cfg.Passwd == "" {
+		cfg.Passwd = lookup("password", "MYSQL_PWD")
+	}
+	if cfg.DBName == "" {
+		cfg.DBName = lookup("database", "")
+	}
+	// The driver fills in 127.0.0.1:3306 itself, so look at the DSN to
+	// see whether an address was given.
+	if !strings.Contains(dsn, "(") {
+		if socket := lookup("socket", "MYSQL_UNIX_PORT"); socket != "" {
+			cfg.Net, cfg.Addr = "unix", socket
+		} else {
+			host, port := lookup("host", "MYSQL_HOST"), lookup("port", "MYSQL_TCP_PORT")
+			if host == "" {
+				host = "127.0.0.1"
+			}
+			if port == "" {
+				port = "3306"
+			}
+			cfg.Net, cfg.Addr = "tcp", net.JoinHostPort(host, port)
+		}
+	}
+
+	info := db.ConnInfo{
+		Host:     cfg.Addr,
+		Database: cfg.DBName,
+		User:     cfg.User,
+		Password: cfg.Passwd != "",
+		Sources:  sources,
+		TLS:      dsnTLS(cfg),
+	}
+	if host, port, err := net.SplitHostPort(cfg.Addr); err == nil && cfg.Net == "tcp" {
+		info.Host, info.Port = host, port
+	}
+	return cfg.FormatDSN(), info, nil
+}
+
+// dsnTLS is the TLS mode cfg's tls= parameter asks for.
+func dsnTLS(cfg *mysql.Config) *db.TLS {
+	switch {
+	case cfg.TLS == nil:
+		return &db.TLS{Mode: db.TLSDisable}
+	case cfg.AllowFallbackToPlaintext:
+		return &db.TLS{Mode: db.TLSPrefer}
+	case cfg.TLS.InsecureSkipVerify:
+		return &db.TLS{Mode: db.TLSRequire}
+	}
+	return &db.TLS{Mode: db.TLSVerifyFull}
+}
+
+// WithPassword sets the password of dsn, a DSN as Resolve returns it.
+func WithPassword(dsn, password string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.Passwd = password
+	return cfg.FormatDSN(), nil
+}
+
+// WithAddress points dsn, a DSN as Resolve returns it, at host:port.
+func WithAddress(dsn, host, port string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.Net, cfg.Addr = "tcp", net.JoinHostPort(host, port)
+	return cfg.FormatDSN(), nil
+}
+
+// IsAuthError reports whether err is the server rejecting the password.
+func IsAuthError(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1045 // ER_ACCESS_DENIED_ERROR
+}
+
+// optionFiles reads the [client] section of the option files: key ->
+// value, and key -> the file it came from. Keys use "_" for "-".
+func optionFiles() (map[string]string, map[string]string) {
+	paths := []string{"/etc/my.cnf", "/etc/mysql/my.cnf"}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".my.cnf"))
+	}
+	values, files := map[string]string{}, map[string]string{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		section := ""
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			switch {
+			case line == "", line[0] == '#', line[0] == ';', line[0] == '!':
+				continue
+			case line[0] == '[':
+				section = strings.ToLower(strings.Trim(line, "[]"))
+				continue
+			case section != "client":
+				continue
+			}
+			key, value, _ := strings.Cut(line, "=")
+			key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+			value = strings.TrimSpace(value)
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+			values[key], files[key] = value, path
+		}
+		f.Close()
+	}
+	return values, files
+}
+
+func appendOnce(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
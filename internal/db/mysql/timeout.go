@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// timeoutConnector sets a server-side statement timeout on every new
+// connection: max_execution_time on MySQL, which only limits SELECTs,
+// and max_statement_time on MariaDB, which has no max_execution_time.
+type timeoutConnector struct {
+	driver.Connector
+	timeout time.Duration
+}
+
+func (c *timeoutConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exec := conn.(driver.ExecerContext)
+	_, err = exec.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", c.timeout.Milliseconds()), nil)
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == 1193 { // ER_UNKNOWN_SYSTEM_VARIABLE
+		_, err = exec.ExecContext(ctx, fmt.Sprintf("SET SESSION max_statement_time = %g", c.timeout.Seconds()), nil)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// IsTimeoutError reports whether err is the server stopping a statement
+// that ran past its time limit.
+func IsTimeoutError(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) &&
+		(myErr.Number == 3024 || myErr.Number == 1969) // MySQL, MariaDB
+}
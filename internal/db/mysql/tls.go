@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// applyTLS replaces the TLS settings the DSN's tls= gave with t. Unix
+// sockets stay unencrypted.
+func applyTLS(cfg *mysql.Config, t *db.TLS) error {
+	cfg.TLS, cfg.TLSConfig, cfg.AllowFallbackToPlaintext = nil, "", false
+	if t.Disabled() || cfg.Net != "tcp" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+	if cfg.TLS, err = t.Config(host); err != nil {
+		return err
+	}
+	cfg.AllowFallbackToPlaintext = t.Optional()
+	return nil
+}
+
+// TLSInfo asks the server what the session negotiated.
+func (m *MysqlDB) TLSInfo(ctx context.Context) (db.TLSInfo, error) {
+	var info db.TLSInfo
+	rows, err := m.db.QueryContext(ctx, "SHOW SESSION STATUS WHERE Variable_name IN ('Ssl_version', 'Ssl_cipher')")
+	if err != nil {
+		return info, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return info, err
+		}
+		switch name {
+		case "Ssl_version":
+			info.Version = tlsVersionName(value.String)
+		case "Ssl_cipher":
+			info.Cipher = value.String
+		}
+	}
+	return info, rows.Err()
+}
+
+// tlsVersionName turns the server's "TLSv1.3" into Go's "TLS 1.3".
+func tlsVersionName(v string) string {
+	if n, ok := strings.CutPrefix(v, "TLSv"); ok {
+		return "TLS " + n
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Defaults for a small CLI tool, used where Options leaves a setting at
+// zero.
+const (
+	DefaultConnectTimeout  = 5 * time.Second
+	DefaultMaxOpenConns    = 4
+	DefaultConnMaxLifetime = 5 * time.Minute
+)
+
+// Options are the connection settings every network adapter's Open
+// takes besides the DSN. The zero value keeps the defaults.
+type Options struct {
+	// TLS, if not nil, replaces the DSN's TLS parameters.
+	TLS *TLS
+
+	// ServerName, if set, is the name the server certificate is checked
+	// against when the DSN's own TLS parameters are used: the DSN then
+	// names the local end of an SSH tunnel, not the server.
+	ServerName string
+
+	// ConnectTimeout bounds dialling and logging in, for the first
+	// connection and for every one the pool opens later.
+	ConnectTimeout time.Duration
+
+	// QueryTimeout, if set, is pushed to the server as a statement
+	// timeout where the driver allows it: statement_timeout on postgres,
+	// max_execution_time (SELECTs only) on mysql. SQL Server has none, so
+	// there it only bounds lock waits (LOCK_TIMEOUT). The app layer also
+	// puts a deadline on each query's context, which cancels the query
+	// on the server.
+	QueryTimeout time.Duration
+
+	Pool Pool
+}
+
+// Pool sizes the database/sql connection pool.
+type Pool struct {
+	MaxOpenConns    int           // default DefaultMaxOpenConns
+	MaxIdleConns    int           // default MaxOpenConns
+	ConnMaxLifetime time.Duration // default DefaultConnMaxLifetime
+	ConnMaxIdleTime time.Duration // default none
+}
+
+// Timeout is o.ConnectTimeout with the default filled in.
+func (o Options) Timeout() time.Duration {
+	if o.ConnectTimeout > 0 {
+		return o.ConnectTimeout
+	}
+	return DefaultConnectTimeout
+}
+
+// pool is o.Pool with the defaults filled in.
+func (o Options) pool() Pool {
+	p := o.Pool
+	if p.MaxOpenConns <= 0 {
+		p.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if p.MaxIdleConns <= 0 || p.MaxIdleConns > p.MaxOpenConns {
+		p.MaxIdleConns = p.MaxOpenConns
+	}
+	if p.ConnMaxLifetime <= 0 {
+		p.ConnMaxLifetime = DefaultConnMaxLifetime
+	}
+	return p
+}
+
+// Setup applies the pool settings to sqldb and pings it within the
+// connect timeout, closing it if that fails.
+func (o Options) Setup(sqldb *sql.DB) error {
+	p := o.pool()
+	sqldb.SetMaxOpenConns(p.MaxOpenConns)
+	sqldb.SetMaxIdleConns(p.MaxIdleConns)
+	sqldb.SetConnMaxLifetime(p.ConnMaxLifetime)
+	sqldb.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+
+	if err := o.Ping(sqldb); err != nil {
+		sqldb.Close()
+		return err
+	}
+	return nil
+}
+
+// Ping checks sqldb within the connect timeout.
+func (o Options) Ping(sqldb *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout())
+	defer cancel()
+	return sqldb.PingContext(ctx)
+}
+
+// ResetPool closes sqldb's idle connections, keeping the pool settings
+// (see Pinger).
+func (o Options) ResetPool(sqldb *sql.DB) {
+	sqldb.SetMaxIdleConns(0)
+	sqldb.SetMaxIdleConns(o.pool().MaxIdleConns)
+}
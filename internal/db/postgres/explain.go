@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+type pgPlan struct {
+	NodeType        string   `json:"Node Type"`
+	RelationName    string   `json:"Relation Name"`
+	Schema          string   `json:"Schema"`
+	Alias           string   `json:"Alias"`
+	IndexName       string   `json:"Index Name"`
+	JoinType        string   `json:"Join Type"`
+	Filter          string   `json:"Filter"`
+	IndexCond       string   `json:"Index Cond"`
+	HashCond        string   `json:"Hash Cond"`
+	MergeCond       string   `json:"Merge Cond"`
+	TotalCost       float64  `json:"Total Cost"`
+	PlanRows        float64  `json:"Plan Rows"`
+	ActualTotalTime *float64 `json:"Actual Total Time"`
+	ActualRows      float64  `json:"Actual Rows"`
+	ActualLoops     float64  `json:"Actual Loops"`
+	Plans           []pgPlan `json:"Plans"`
+}
+
+func (p *PostgresDB) Explain(ctx context.Context, query string, analyze bool) (*db.PlanNode, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	opts := "FORMAT JSON"
+	if analyze {
+		opts = "ANALYZE, BUFFERS, FORMAT JSON"
+	}
+	stmt := fmt.Sprintf("EXPLAIN (%s) %s", opts, query)
+
+	// Always inside a transaction we roll back, so ANALYZE on DML
+	// never changes data.
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: p.ro.ReadOnlyOn()})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	if err := tx.QueryRowContext(ctx, stmt).Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	var out []struct {
+		Plan pgPlan `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty plan")
+	}
+	return convertPGPlan(out[0].Plan), nil
+}
+
+func convertPGPlan(pl pgPlan) *db.PlanNode {
+	n := &db.PlanNode{
+		Op:       pl.NodeType,
+		Cost:     pl.TotalCost,
+		Rows:     pl.PlanRows,
+		FullScan: pl.NodeType == "Seq Scan",
+	}
+	if pl.JoinType != "" && pl.JoinType != "Inner" {
+		n.Op = pl.NodeType + " (" + pl.JoinType + ")"
+	}
+
+	target := pl.RelationName
+	if target != "" && pl.Schema != "" {
+		target = pl.Schema + "." + target
+	}
+	if pl.IndexName != "" {
+		if target != "" {
+			target += " using " + pl.IndexName
+		} else {
+			target = pl.IndexName
+		}
+	}
+	n.Target = target
+
+	var details []string
+	for _, d := range []string{pl.IndexCond, pl.HashCond, pl.MergeCond, pl.Filter} {
+		if d != "" {
+			details = append(details, d)
+		}
+	}
+	n.Detail = strings.Join(details, "; ")
+
+	if pl.ActualTotalTime != nil {
+		loops := pl.ActualLoops
+		if loops == 0 {
+			loops = 1
+		}
+		// postgres reports per-loop averages
+		n.ActualMs = *pl.ActualTotalTime * loops
+		n.ActualRows = pl.ActualRows * loops
+		n.Loops = loops
+	}
+
+	for _, child := range pl.Plans {
+		n.Children = append(n.Children, convertPGPlan(child))
+	}
+	return n
+}
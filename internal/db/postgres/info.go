@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// ServerInfo reports the server and one pooled session (db.InfoReporter).
+func (p *PostgresDB) ServerInfo(ctx context.Context) (*db.ServerInfo, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	const q = `
+SELECT version(), current_database(), coalesce(current_schema(), ''), current_user,
+       current_setting('TimeZone'), current_setting('server_encoding'),
+       current_setting('client_encoding'), current_setting('search_path'),
+       current_setting('transaction_isolation'), pg_backend_pid()`
+	info := &db.ServerInfo{Size: -1}
+	var clientEnc, searchPath, isolation string
+	var pid int64
+	if err := conn.QueryRowContext(ctx, q).Scan(&info.Version, &info.Database, &info.Schema, &info.User,
+		&info.TimeZone, &info.Encoding, &clientEnc, &searchPath, &isolation, &pid); err != nil {
+		return nil, err
+	}
+	info.ConnectionID = strconv.FormatInt(pid, 10)
+	info.Settings = []db.Setting{
+		{Name: "search_path", Value: searchPath},
+		{Name: "transaction_isolation", Value: isolation},
+		{Name: "client_encoding", Value: clientEnc},
+	}
+	for _, name := range []string{"statement_timeout", "lock_timeout", "idle_in_transaction_session_timeout", "default_transaction_read_only"} {
+		var v string
+		if conn.QueryRowContext(ctx, "SELECT current_setting($1)", name).Scan(&v) == nil {
+			info.Settings = append(info.Settings, db.Setting{Name: name, Value: v})
+		}
+	}
+
+	// Roles current_user is a member of, directly or through others.
+	rows, err := conn.QueryContext(ctx, `
+SELECT rolname FROM pg_roles
+WHERE rolname <> current_user AND pg_has_role(current_user, oid, 'MEMBER')
+ORDER BY rolname`)
+	if err == nil {
+		for rows.Next() {
+			var r string
+			if rows.Scan(&r) == nil {
+				info.Roles = append(info.Roles, r)
+			}
+		}
+		rows.Close()
+	}
+
+	// Needs CONNECT on the database, which binsql has.
+	var size sql.NullInt64
+	if conn.QueryRowContext(ctx, "SELECT pg_database_size(current_database())").Scan(&size) == nil && size.Valid {
+		info.Size = size.Int64
+	}
+	return info, nil
+}
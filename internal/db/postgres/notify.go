@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Listen opens its own pgx connection: LISTEN registrations belong to a
+// session, and the pooled *sql.DB may hand queries to any connection.
+func (p *PostgresDB) Listen(ctx context.Context, channels []string, fn func(db.Notification)) error {
+	if len(channels) == 0 {
+		return fmt.Errorf("no channels to listen on")
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, p.cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for _, ch := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{ch}.Sanitize()); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		fn(db.Notification{
+			Time:    time.Now(),
+			Channel: n.Channel,
+			Payload: n.Payload,
+			PID:     n.PID,
+		})
+	}
+}
+
+func (p *PostgresDB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
@@ -3,43 +3,55 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"strings"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // register pgx stdlib driver
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 
+	"github.com/bgunnarsson/binsql/internal/blob"
 	"github.com/bgunnarsson/binsql/internal/db"
 )
 
 type PostgresDB struct {
-	db *sql.DB
-}
+	db   *sql.DB
+	cfg  *pgx.ConnConfig // kept for dedicated connections (LISTEN)
+	opts db.Options      // for ResetPool
+	ro   db.ReadOnly
 
-func Open(dsn string) (*PostgresDB, error) {
-	if dsn == "" {
-		return nil, fmt.Errorf("empty postgres DSN")
-	}
+	typeNames map[string]string // OID -> format_type() for non-builtin types
+}
 
-	sqldb, err := sql.Open("pgx", dsn)
+// Open connects to dsn. An empty DSN is allowed: like psql, pgx then
+// connects using the PG* environment variables and defaults. A non-nil
+// o.TLS replaces the DSN's sslmode settings.
+func Open(dsn string, o db.Options) (*PostgresDB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
-
-	// Sane defaults for a small CLI tool.
-	sqldb.SetMaxOpenConns(4)
-	sqldb.SetMaxIdleConns(4)
-	sqldb.SetConnMaxLifetime(5 * time.Minute)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := sqldb.PingContext(ctx); err != nil {
-		sqldb.Close()
+	if o.TLS != nil {
+		if err := applyTLS(&cfg.Config, o.TLS); err != nil {
+			return nil, err
+		}
+	} else if o.ServerName != "" {
+		setServerName(&cfg.Config, o.ServerName)
+	}
+	if o.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = o.ConnectTimeout
+	}
+	var opts []stdlib.OptionOpenDB
+	if o.QueryTimeout > 0 {
+		opts = append(opts, stdlib.OptionAfterConnect(statementTimeout(o.QueryTimeout)))
+	}
+	sqldb := stdlib.OpenDB(*cfg, opts...)
+	if err := o.Setup(sqldb); err != nil {
 		return nil, err
 	}
 
-	return &PostgresDB{db: sqldb}, nil
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout())
+	defer cancel()
+	return &PostgresDB{db: sqldb, cfg: cfg, opts: o, typeNames: loadTypeNames(ctx, sqldb)}, nil
 }
 
 func (p *PostgresDB) Close() error {
@@ -49,6 +61,16 @@ func (p *PostgresDB) Close() error {
 	return p.db.Close()
 }
 
+// Ping checks that the server can still be reached (db.Pinger).
+func (p *PostgresDB) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// ResetPool drops idle connections (db.Pinger).
+func (p *PostgresDB) ResetPool() {
+	p.opts.ResetPool(p.db)
+}
+
 func (p *PostgresDB) ListTables(ctx context.Context) ([]string, error) {
 	const q = `
 SELECT table_schema || '.' || table_name AS name
@@ -117,8 +139,18 @@ ORDER BY ordinal_position;
 	return cols, nil
 }
 
+// SetReadOnly has Query run in read-only transactions (see
+// db.ReadOnlySetter).
+func (p *PostgresDB) SetReadOnly(on bool) { p.ro.SetReadOnly(on) }
+
 func (p *PostgresDB) Query(ctx context.Context, sqlQuery string, args ...any) (*db.Rows, error) {
-	rows, err := p.db.QueryContext(ctx, sqlQuery, args...)
+	q, done, err := p.ro.Begin(ctx, p.db, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	rows, err := q.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,18 +166,25 @@ func (p *PostgresDB) Query(ctx context.Context, sqlQuery string, args ...any) (*
 		return nil, err
 	}
 
-	header := make([]db.Column, len(colNames))
-	for i, name := range colNames {
-		typ := ""
+	typeNames := make([]string, len(colNames))
+	for i := range colNames {
 		if i < len(colTypes) && colTypes[i] != nil {
-			typ = strings.ToLower(colTypes[i].DatabaseTypeName())
+			typeNames[i] = p.typeName(colTypes[i].DatabaseTypeName())
 		}
+	}
+
+	header := make([]db.Column, len(colNames))
+	for i, name := range colNames {
 		header[i] = db.Column{
 			Name: name,
-			Type: typ,
+			Type: typeNames[i],
 		}
 	}
 
+	// pgx's database/sql driver reads all but a few intrinsic types
+	// (numbers, bool, bytea, dates, timestamps) in text format, so
+	// ranges, intervals, inet/cidr, hstore, arrays and composite rows
+	// arrive as strings in postgres' own text form.
 	var data []db.Row
 	for rows.Next() {
 		values := make([]any, len(colNames))
@@ -161,9 +200,14 @@ func (p *PostgresDB) Query(ctx context.Context, sqlQuery string, args ...any) (*
 		for i, v := range values {
 			switch x := v.(type) {
 			case []byte:
-				values[i] = string(x)
+				// keep bytea as bytes for the blob viewer
+				if i < len(header) && blob.IsBinaryColumn(header[i].Type) {
+					values[i] = x
+				} else {
+					values[i] = string(x)
+				}
 			case time.Time:
-				values[i] = x.Format(time.RFC3339Nano)
+				values[i] = formatTime(x, header[i].Type)
 			default:
 				values[i] = x
 			}
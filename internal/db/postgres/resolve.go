@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgpassfile"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// envVars are the libpq environment variables reported as sources.
+var envVars = []string{
+	"PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD",
+	"PGPASSFILE", "PGSERVICE", "PGSERVICEFILE", "PGSSLMODE",
+}
+
+// Resolve completes dsn the way psql does: PG* environment variables,
+// service= entries from ~/.pg_service.conf and passwords from ~/.pgpass
+// (pgx reads them all when connecting). dsn may be empty. The DSN itself
+// is returned unchanged.
+func Resolve(dsn string) (string, db.ConnInfo, error) {
+	cfg, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		return "", db.ConnInfo{}, err
+	}
+	info := db.ConnInfo{
+		Host:     cfg.Host,
+		Port:     strconv.Itoa(int(cfg.Port)),
+		Database: cfg.Database,
+		User:     cfg.User,
+		Password: cfg.Password != "",
+		TLS:      dsnTLS(cfg),
+	}
+
+	for _, name := range envVars {
+		if os.Getenv(name) != "" {
+			info.Sources = append(info.Sources, name)
+		}
+	}
+	if service := dsnSetting(dsn, "service"); service != "" {
+		info.Sources = append(info.Sources, "service "+service)
+	}
+	if cfg.Password != "" && os.Getenv("PGPASSWORD") == "" && dsnSetting(dsn, "password") == "" {
+		if path, ok := passfileMatch(cfg); ok {
+			info.Sources = append(info.Sources, path)
+		}
+	}
+	return dsn, info, nil
+}
+
+// dsnTLS is the TLS mode sslmode (from the DSN, PGSSLMODE or a service
+// file) gave cfg. pgx turns prefer and allow into a TLS and a plain text
+// attempt, and verify-ca into a custom certificate check.
+func dsnTLS(cfg *pgconn.Config) *db.TLS {
+	tc, plain := cfg.TLSConfig, cfg.TLSConfig == nil
+	for _, f := range cfg.Fallbacks {
+		if f.TLSConfig == nil {
+			plain = true
+		} else if tc == nil {
+			tc = f.TLSConfig
+		}
+	}
+	switch {
+	case tc == nil:
+		return &db.TLS{Mode: db.TLSDisable}
+	case plain:
+		return &db.TLS{Mode: db.TLSPrefer}
+	case tc.VerifyPeerCertificate != nil:
+		return &db.TLS{Mode: db.TLSVerifyCA}
+	case tc.InsecureSkipVerify:
+		return &db.TLS{Mode: db.TLSRequire}
+	}
+	return &db.TLS{Mode: db.TLSVerifyFull}
+}
+
+// dsnSetting returns key from a postgres URL or keyword/value DSN.
+func dsnSetting(dsn, key string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return ""
+		}
+		if key == "password" && u.User != nil {
+			if pw, ok := u.User.Password(); ok {
+				return pw
+			}
+		}
+		return u.Query().Get(key)
+	}
+	for _, field := range strings.Fields(dsn) {
+		if k, v, ok := strings.Cut(field, "="); ok && k == key {
+			return strings.Trim(v, "'")
+		}
+	}
+	return ""
+}
+
+// passfileMatch reports whether cfg's password is the one the passfile
+// gives, and the passfile's path.
+func passfileMatch(cfg *pgconn.Config) (string, bool) {
+	path := os.Getenv("PGPASSFILE")
+	if path == "" {
+		u, err := user.Current() // as pgx does
+		if err != nil {
+			return "", false
+		}
+		path = filepath.Join(u.HomeDir, ".pgpass")
+	}
+	pf, err := pgpassfile.ReadPassfile(path)
+	if err != nil {
+		return "", false
+	}
+	host := cfg.Host
+	if strings.HasPrefix(host, "/") {
+		host = "localhost" // unix socket, as pgx looks it up
+	}
+	if pf.FindPassword(host, strconv.Itoa(int(cfg.Port)), cfg.Database, cfg.User) != cfg.Password {
+		return "", false
+	}
+	return path, true
+}
+
+// WithPassword sets the password of dsn, a URL or keyword/value DSN.
+func WithPassword(dsn, password string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String(), nil
+	}
+	quoted := "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(password) + "'"
+	return strings.TrimSpace(dsn + " password=" + quoted), nil
+}
+
+// WithAddress points dsn, a URL or keyword/value DSN, at host:port.
+func WithAddress(dsn, host, port string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Host = net.JoinHostPort(host, port)
+		q := u.Query()
+		q.Del("host")
+		q.Del("hostaddr")
+		q.Del("port")
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	// Later keywords win.
+	return strings.TrimSpace(dsn + " host=" + host + " port=" + port), nil
+}
+
+// IsAuthError reports whether err is the server rejecting the password.
+func IsAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "28P01" // invalid_password
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// statementTimeout sets statement_timeout on every new connection, so
+// the server gives up on a query even if the client goes away. It is a
+// SET rather than a startup parameter, which PgBouncer would refuse.
+func statementTimeout(d time.Duration) func(context.Context, *pgx.Conn) error {
+	set := fmt.Sprintf("SET statement_timeout = %d", d.Milliseconds())
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, set)
+		return err
+	}
+}
+
+// IsTimeoutError reports whether err is the server cancelling a query
+// for exceeding statement_timeout.
+func IsTimeoutError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014" && // query_canceled
+		strings.Contains(pgErr.Message, "statement timeout")
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// applyTLS replaces the TLS settings pgx derived from sslmode with t,
+// for every host of a multi-host DSN. Unix sockets stay unencrypted.
+func applyTLS(cfg *pgconn.Config, t *db.TLS) error {
+	hosts := append([]*pgconn.FallbackConfig{{Host: cfg.Host, Port: cfg.Port}}, cfg.Fallbacks...)
+	seen := map[string]bool{} // sslmode=prefer lists every host twice
+	var out []*pgconn.FallbackConfig
+	for _, h := range hosts {
+		key := h.Host + ":" + strconv.Itoa(int(h.Port))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if t.Disabled() || strings.HasPrefix(h.Host, "/") {
+			out = append(out, &pgconn.FallbackConfig{Host: h.Host, Port: h.Port})
+			continue
+		}
+		tc, err := t.Config(h.Host)
+		if err != nil {
+			return err
+		}
+		out = append(out, &pgconn.FallbackConfig{Host: h.Host, Port: h.Port, TLSConfig: tc})
+		if t.Optional() {
+			out = append(out, &pgconn.FallbackConfig{Host: h.Host, Port: h.Port})
+		}
+	}
+	cfg.Host, cfg.Port, cfg.TLSConfig = out[0].Host, out[0].Port, out[0].TLSConfig
+	cfg.Fallbacks = out[1:]
+	return nil
+}
+
+// setServerName makes the TLS pgx derived from sslmode check (and send
+// as SNI) name rather than the DSN's host.
+func setServerName(cfg *pgconn.Config, name string) {
+	set := func(tc *tls.Config) {
+		if tc != nil {
+			tc.ServerName = name
+		}
+	}
+	set(cfg.TLSConfig)
+	for _, f := range cfg.Fallbacks {
+		set(f.TLSConfig)
+	}
+}
+
+// TLSInfo reports the TLS state of a pooled connection.
+func (p *PostgresDB) TLSInfo(ctx context.Context) (db.TLSInfo, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return db.TLSInfo{}, err
+	}
+	defer conn.Close()
+
+	var info db.TLSInfo
+	err = conn.Raw(func(dc any) error {
+		sc, ok := dc.(*stdlib.Conn)
+		if !ok {
+			return driver.ErrBadConn
+		}
+		if tc, ok := sc.Conn().PgConn().Conn().(*tls.Conn); ok {
+			state := tc.ConnectionState()
+			info.Version = tls.VersionName(state.Version)
+			info.Cipher = tls.CipherSuiteName(state.CipherSuite)
+		}
+		return nil
+	})
+	return info, err
+}
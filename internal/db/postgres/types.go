@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// pgx only knows the built-in types by name; extension and user types
+// (hstore, citext, enums, composites, domains) come back as a bare OID.
+// loadTypeNames reads the names of all of them from pg_type once, when
+// the connection opens, so naming a result's columns needs no extra
+// round trip. Types created later keep their OID.
+func loadTypeNames(ctx context.Context, sqldb *sql.DB) map[string]string {
+	const q = `
+SELECT oid::text, format_type(oid, NULL)
+FROM pg_catalog.pg_type
+WHERE oid >= 16384; -- FirstNormalObjectId: not built in
+`
+	names := map[string]string{}
+	rows, err := sqldb.QueryContext(ctx, q)
+	if err != nil {
+		return names // keep the OIDs; better than failing to connect
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var oid, name string
+		if err := rows.Scan(&oid, &name); err != nil {
+			break
+		}
+		names[oid] = name
+	}
+	return names
+}
+
+// typeName is the SQL name of a column type as pgx reports it: a
+// built-in name, or the OID of a type loadTypeNames may know.
+func (p *PostgresDB) typeName(name string) string {
+	if known, ok := p.typeNames[name]; ok {
+		return known
+	}
+	return normalizeTypeName(name)
+}
+
+// normalizeTypeName turns pgx's internal array names ("_int4") into
+// the SQL spelling ("int4[]").
+func normalizeTypeName(name string) string {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "_") {
+		return name[1:] + "[]"
+	}
+	return name
+}
+
+// formatTime renders time values the way postgres prints them, so the
+// text can be pasted back into a query. pgx hands date/timestamp
+// columns over as time.Time, which would otherwise print as RFC3339.
+func formatTime(t time.Time, typ string) string {
+	switch typ {
+	case "date":
+		return t.Format("2006-01-02")
+	case "timestamp":
+		return t.Format("2006-01-02 15:04:05.999999")
+	case "timestamptz":
+		return t.Format("2006-01-02 15:04:05.999999-07:00")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// Querier is what *sql.DB and *sql.Tx have in common for reading rows.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ReadOnly is the switch adapters embed to implement ReadOnlySetter.
+// The zero value is off.
+type ReadOnly struct {
+	on atomic.Bool
+}
+
+func (r *ReadOnly) SetReadOnly(on bool) { r.on.Store(on) }
+
+// ReadOnlyOn reports whether read-only is on.
+func (r *ReadOnly) ReadOnlyOn() bool { return r.on.Load() }
+
+// Begin returns what Query should run its statement on: sqldb itself,
+// or with read-only on, a transaction begun with opts. done ends the
+// transaction with a rollback; call it once the rows are read.
+func (r *ReadOnly) Begin(ctx context.Context, sqldb *sql.DB, opts *sql.TxOptions) (q Querier, done func(), err error) {
+	if !r.on.Load() {
+		return sqldb, func() {}, nil
+	}
+	tx, err := sqldb.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, func() { tx.Rollback() }, nil
+}
@@ -0,0 +1,296 @@
+// Package schemacache keeps the database catalog (tables and their
+// columns) in memory and on disk, so the TUI does not hit the server for
+// every ListTables/DescribeTable call. It sits in front of a db.DB and
+// implements db.DB itself.
+package schemacache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// describeWorkers bounds concurrent DescribeTable calls during a refresh.
+const describeWorkers = 4
+
+type snapshot struct {
+	Tables   []string               `json:"tables"`
+	Columns  map[string][]db.Column `json:"columns"`
+	LoadedAt time.Time              `json:"loaded_at"`
+}
+
+type Options struct {
+	// Path of the on-disk copy; empty disables persistence.
+	Path string
+	// TTL triggers a background refresh when the catalog is older; zero
+	// means refresh only on demand.
+	TTL time.Duration
+}
+
+type Cache struct {
+	inner db.DB
+	opts  Options
+
+	mu         sync.RWMutex
+	snap       snapshot
+	refreshing bool
+	lastErr    error
+	onChange   func()
+}
+
+// New wraps inner. A previously persisted catalog is loaded immediately.
+func New(inner db.DB, opts Options) *Cache {
+	c := &Cache{
+		inner: inner,
+		opts:  opts,
+		snap:  snapshot{Columns: map[string][]db.Column{}},
+	}
+	c.load()
+	return c
+}
+
+// PathFor returns the default cache file for a connection. The DSN is
+// hashed so credentials never end up in file names.
+func PathFor(driver, dsn string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(driver + "\x00" + dsn))
+	return filepath.Join(dir, "binsql", "schema", hex.EncodeToString(sum[:8])+".json")
+}
+
+// SetOnChange registers fn to be called (from a background goroutine)
+// after each completed refresh.
+func (c *Cache) SetOnChange(fn func()) {
+	c.mu.Lock()
+	c.onChange = fn
+	c.mu.Unlock()
+}
+
+// Start refreshes the catalog once in the background and then every
+// TTL, until ctx is done.
+func (c *Cache) Start(ctx context.Context) {
+	go func() {
+		_ = c.Refresh(ctx)
+		if c.opts.TTL <= 0 {
+			return
+		}
+		t := time.NewTicker(c.opts.TTL)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_ = c.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh reloads tables and all column lists from the database and
+// persists the result. Concurrent calls collapse into one.
+func (c *Cache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	if c.refreshing {
+		c.mu.Unlock()
+		return nil
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+
+	snap, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	c.refreshing = false
+	c.lastErr = err
+	if err == nil {
+		c.snap = snap
+	}
+	onChange := c.onChange
+	c.mu.Unlock()
+
+	if err == nil {
+		c.save()
+	}
+	if onChange != nil {
+		onChange()
+	}
+	return err
+}
+
+func (c *Cache) fetch(ctx context.Context) (snapshot, error) {
+	tables, err := c.inner.ListTables(ctx)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	snap := snapshot{
+		Tables:   tables,
+		Columns:  make(map[string][]db.Column, len(tables)),
+		LoadedAt: time.Now(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+	for i := 0; i < describeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range work {
+				cols, err := c.inner.DescribeTable(ctx, t)
+				if err != nil {
+					continue // one odd table should not sink the catalog
+				}
+				mu.Lock()
+				snap.Columns[t] = cols
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, t := range tables {
+		select {
+		case work <- t:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return snapshot{}, err
+	}
+	return snap, nil
+}
+
+// Refreshing reports whether a background refresh is in progress.
+func (c *Cache) Refreshing() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshing
+}
+
+// LoadedAt is when the current catalog was fetched (zero if never).
+func (c *Cache) LoadedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snap.LoadedAt
+}
+
+// Err returns the error of the last refresh, if it failed.
+func (c *Cache) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr
+}
+
+// Tables returns the cached table list without touching the database.
+func (c *Cache) Tables() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.snap.Tables...)
+}
+
+// Columns returns cached columns without touching the database.
+func (c *Cache) Columns(table string) ([]db.Column, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cols, ok := c.snap.Columns[table]
+	return cols, ok
+}
+
+// --- db.DB implementation ---
+
+func (c *Cache) ListTables(ctx context.Context) ([]string, error) {
+	c.mu.RLock()
+	loaded := c.snap.Tables != nil
+	c.mu.RUnlock()
+	if loaded {
+		return c.Tables(), nil
+	}
+
+	tables, err := c.inner.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.snap.Tables = tables
+	c.mu.Unlock()
+	return append([]string(nil), tables...), nil
+}
+
+func (c *Cache) DescribeTable(ctx context.Context, table string) ([]db.Column, error) {
+	if cols, ok := c.Columns(table); ok {
+		return cols, nil
+	}
+	cols, err := c.inner.DescribeTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.snap.Columns[table] = cols
+	c.mu.Unlock()
+	return cols, nil
+}
+
+func (c *Cache) Query(ctx context.Context, sql string, args ...any) (*db.Rows, error) {
+	return c.inner.Query(ctx, sql, args...)
+}
+
+func (c *Cache) Close() error {
+	return c.inner.Close()
+}
+
+// Unwrap exposes the adapter for optional interfaces (see db.Unwrap).
+func (c *Cache) Unwrap() db.DB {
+	return c.inner
+}
+
+// --- persistence ---
+
+func (c *Cache) load() {
+	if c.opts.Path == "" {
+		return
+	}
+	b, err := os.ReadFile(c.opts.Path)
+	if err != nil {
+		return
+	}
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return // corrupt or old format; the next refresh rewrites it
+	}
+	if snap.Columns == nil {
+		snap.Columns = map[string][]db.Column{}
+	}
+	c.snap = snap
+}
+
+func (c *Cache) save() {
+	if c.opts.Path == "" {
+		return
+	}
+	c.mu.RLock()
+	b, err := json.Marshal(c.snap)
+	c.mu.RUnlock()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.opts.Path), 0o700); err != nil {
+		return
+	}
+	tmp := c.opts.Path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.opts.Path)
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Explain runs EXPLAIN QUERY PLAN. SQLite has no ANALYZE variant that
+// reports timings, so analyze mode is rejected rather than faked.
+func (s *SqliteDB) Explain(ctx context.Context, query string, analyze bool) (*db.PlanNode, error) {
+	if analyze {
+		return nil, fmt.Errorf("sqlite has no EXPLAIN ANALYZE; use the estimated plan")
+	}
+
+	rows, err := s.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+strings.TrimSpace(query))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	root := &db.PlanNode{Op: "QUERY PLAN"}
+	byID := map[int]*db.PlanNode{0: root}
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		n := &db.PlanNode{Op: detail}
+		if strings.HasPrefix(detail, "SCAN ") && !strings.Contains(detail, "INDEX") {
+			n.FullScan = true
+		}
+		byID[id] = n
+		p, ok := byID[parent]
+		if !ok {
+			p = root
+		}
+		p.Children = append(p.Children, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// ServerInfo reports the library version, the database file and its
+// pragmas (db.InfoReporter). SQLite has no users or time zone.
+func (s *SqliteDB) ServerInfo(ctx context.Context) (*db.ServerInfo, error) {
+	info := &db.ServerInfo{Size: -1}
+	if err := s.db.QueryRowContext(ctx, "SELECT 'SQLite ' || sqlite_version()").Scan(&info.Version); err != nil {
+		return nil, err
+	}
+
+	var seq int
+	var name, file string
+	if s.db.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &name, &file) == nil {
+		info.Database = file
+		if file == "" {
+			info.Database = ":memory:"
+		}
+	}
+	var pageCount, pageSize int64
+	if s.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount) == nil &&
+		s.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize) == nil {
+		info.Size = pageCount * pageSize
+	}
+	_ = s.db.QueryRowContext(ctx, "PRAGMA encoding").Scan(&info.Encoding)
+
+	for _, pragma := range []string{"journal_mode", "foreign_keys", "synchronous", "busy_timeout", "user_version"} {
+		var v any
+		if s.db.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(&v) == nil {
+			info.Settings = append(info.Settings, db.Setting{Name: pragma, Value: fmt.Sprint(v)})
+		}
+	}
+	return info, nil
+}
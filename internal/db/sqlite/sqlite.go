@@ -14,6 +14,7 @@ import (
 
 type SqliteDB struct {
 	db *sql.DB
+	ro db.ReadOnly
 }
 
 func Open(path string) (*SqliteDB, error) {
@@ -93,8 +94,18 @@ func (s *SqliteDB) DescribeTable(ctx context.Context, table string) ([]db.Column
 	return cols, rows.Err()
 }
 
+// SetReadOnly has Query run in a transaction that is rolled back (see
+// db.ReadOnlySetter); SQLite undoes even schema changes.
+func (s *SqliteDB) SetReadOnly(on bool) { s.ro.SetReadOnly(on) }
+
 func (s *SqliteDB) Query(ctx context.Context, sqlStr string, args ...any) (*db.Rows, error) {
-	rows, err := s.db.QueryContext(ctx, sqlStr, args...)
+	q, done, err := s.ro.Begin(ctx, s.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	rows, err := q.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +138,10 @@ func (s *SqliteDB) Query(ctx context.Context, sqlStr string, args ...any) (*db.R
 		if err := rows.Scan(ptrs...); err != nil {
 			return nil, err
 		}
+		// Values come back by storage class: int64, float64, string,
+		// and []byte for BLOBs, which are kept as bytes (like bytea and
+		// VARBINARY in the other adapters) for the blob viewer and
+		// lossless exports.
 		data = append(data, db.Row(raw))
 	}
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLS modes, named as in libpq's sslmode.
+const (
+	TLSDisable    = "disable"     // never encrypt
+	TLSPrefer     = "prefer"      // encrypt if the server can, without checking its certificate
+	TLSRequire    = "require"     // always encrypt, without checking the certificate
+	TLSVerifyCA   = "verify-ca"   // also check the certificate is signed by a trusted CA
+	TLSVerifyFull = "verify-full" // also check it is for the host connected to
+)
+
+// TLS is a connection's TLS setup, the same for every driver. Each
+// adapter's Open translates it to its driver's settings; a nil *TLS
+// leaves TLS to the DSN.
+type TLS struct {
+	Mode       string // one of the TLS* modes; empty means verify-full
+	CAFile     string // PEM CA certificates; default: the system roots
+	CertFile   string // client certificate and key, PEM
+	KeyFile    string
+	ServerName string // name the certificate must have; default: the host
+	SkipVerify bool   // encrypt but accept any certificate
+}
+
+// mode is t.Mode with the default filled in.
+func (t *TLS) mode() string {
+	if t.Mode == "" {
+		return TLSVerifyFull
+	}
+	return t.Mode
+}
+
+// Disabled reports whether t turns TLS off.
+func (t *TLS) Disabled() bool { return t.mode() == TLSDisable }
+
+// Optional reports whether the connection may fall back to plain text
+// when the server does not offer TLS.
+func (t *TLS) Optional() bool { return t.mode() == TLSPrefer }
+
+// Verified reports whether the server certificate is checked.
+func (t *TLS) Verified() bool {
+	m := t.mode()
+	return !t.SkipVerify && (m == TLSVerifyCA || m == TLSVerifyFull)
+}
+
+// Check validates the mode and loads the files, so mistakes show up
+// when a connection is saved rather than when it is used.
+func (t *TLS) Check() error {
+	_, err := t.Config("localhost")
+	return err
+}
+
+// Config builds the crypto/tls settings for connecting to host. It is
+// nil when t disables TLS.
+func (t *TLS) Config(host string) (*tls.Config, error) {
+	mode := t.mode()
+	switch mode {
+	case TLSDisable:
+		return nil, nil
+	case TLSPrefer, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		return nil, fmt.Errorf("unknown TLS mode %q (expected disable, prefer, require, verify-ca or verify-full)", t.Mode)
+	}
+
+	cfg := &tls.Config{ServerName: host}
+	if t.ServerName != "" {
+		cfg.ServerName = t.ServerName
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificates", t.CAFile)
+		}
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, errors.New("a TLS client certificate needs both a certificate and a key file")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	switch {
+	case t.SkipVerify || mode == TLSPrefer || mode == TLSRequire:
+		cfg.InsecureSkipVerify = true
+	case mode == TLSVerifyCA:
+		// Check the chain but not the name, which crypto/tls cannot do
+		// on its own.
+		cfg.InsecureSkipVerify = true
+		roots := cfg.RootCAs
+		cfg.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			certs := make([]*x509.Certificate, len(raw))
+			for i, b := range raw {
+				c, err := x509.ParseCertificate(b)
+				if err != nil {
+					return err
+				}
+				certs[i] = c
+			}
+			if len(certs) == 0 {
+				return errors.New("server sent no certificate")
+			}
+			opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+			for _, c := range certs[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := certs[0].Verify(opts)
+			return err
+		}
+	}
+	return cfg, nil
+}
+
+// TLSInfo is what a connection negotiated. Version is empty when the
+// connection is not encrypted.
+type TLSInfo struct {
+	Version string // "TLS 1.3"; "encrypted" when the server does not say
+	Cipher  string // cipher suite, if known
+}
+
+// TLSReporter is implemented by adapters that can tell how their
+// connection is encrypted. The app layer checks for it with a type
+// assertion.
+type TLSReporter interface {
+	TLSInfo(ctx context.Context) (TLSInfo, error)
+}
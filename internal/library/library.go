@@ -0,0 +1,319 @@
+// Package library loads saved queries: .sql files with an optional
+// comment header, kept in the user's config directory and in project
+// libraries (.binsql/queries in the working directory or a parent) that
+// can be committed next to the code.
+//
+//	-- description: Longest running statements
+//	-- tags: postgres, perf
+//	-- driver: postgres
+//	-- param limit: 20
+//	SELECT pid, query FROM pg_stat_activity ORDER BY query_start LIMIT :limit
+//
+// The file name (without .sql) is the query's name. :name placeholders
+// are parameters; "param" lines give them defaults and, optionally, a
+// type ("-- param limit number: 20", "-- param owner text?:").
+package library
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/config"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// Query is one saved query.
+type Query struct {
+	Name        string
+	Description string
+	Tags        []string
+	Driver      string // only offered for this driver; "" = any
+	SQL         string
+	Params      []Param // in order of first use
+	Path        string
+	Source      string // "user" or "project"
+}
+
+// Param is a :name placeholder of a query.
+type Param struct {
+	Name       string
+	Type       ParamType
+	Nullable   bool // declared with a trailing "?": the value null binds NULL
+	Default    string
+	HasDefault bool
+}
+
+// Decl is the parameter's declared type as written in the header, e.g.
+// "number" or "text?"; "" for a plain text parameter.
+func (p Param) Decl() string {
+	t := string(p.Type)
+	if p.Type == Text {
+		t = ""
+	}
+	if p.Nullable {
+		t = string(p.Type) + "?"
+	}
+	return t
+}
+
+// ParamType says how a parameter's value is written into the SQL.
+type ParamType string
+
+const (
+	Text   ParamType = "text"   // a quoted string (the default)
+	Number ParamType = "number" // a bare number; anything else is an error
+)
+
+// paramTypes maps the names accepted in a "param" line to a type.
+var paramTypes = map[string]ParamType{
+	"": Text, "text": Text, "string": Text,
+	"number": Number, "int": Number, "integer": Number, "numeric": Number, "float": Number,
+}
+
+// paramDecl is a "param" header line: its type and default.
+type paramDecl struct {
+	typ        ParamType
+	nullable   bool
+	value      string
+	hasDefault bool
+}
+
+// UserDir is the per-user library.
+func UserDir() string {
+	dir := config.Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "queries")
+}
+
+// ProjectDir finds .binsql/queries in start or the nearest parent; "" if
+// there is none.
+func ProjectDir(start string) string {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(dir, ".binsql", "queries")
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the user library and the project library for the working
+// directory. A project query replaces a user query of the same name.
+// The result is sorted by name.
+func Load() ([]*Query, error) {
+	byName := map[string]*Query{}
+	cwd, _ := os.Getwd()
+	for _, lib := range []struct{ dir, source string }{
+		{UserDir(), "user"},
+		{ProjectDir(cwd), "project"},
+	} {
+		qs, err := loadDir(lib.dir, lib.source)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range qs {
+			byName[q.Name] = q
+		}
+	}
+
+	out := make([]*Query, 0, len(byName))
+	for _, q := range byName {
+		out = append(out, q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func loadDir(dir, source string) ([]*Query, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []*Query
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".sql") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		q := Parse(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())), string(b))
+		q.Path, q.Source = path, source
+		out = append(out, q)
+	}
+	return out, nil
+}
+
+// Find returns the query called name.
+func Find(qs []*Query, name string) (*Query, bool) {
+	for _, q := range qs {
+		if q.Name == name {
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+// Parse reads the comment header ("-- key: value" lines before the
+// first statement line) and finds the parameters of src.
+func Parse(name, src string) *Query {
+	q := &Query{Name: name}
+	decls := map[string]paramDecl{}
+
+	lines := strings.Split(src, "\n")
+	body := 0
+	for ; body < len(lines); body++ {
+		line := strings.TrimSpace(lines[body])
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "--")), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch {
+		case key == "name":
+			q.Name = value
+		case key == "description":
+			q.Description = value
+		case key == "tags":
+			q.Tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+		case key == "driver":
+			q.Driver = strings.ToLower(value)
+		case strings.HasPrefix(key, "param "):
+			// "param name [type][?]"; an unknown type is kept and
+			// reported by Bind.
+			f := strings.Fields(strings.TrimPrefix(key, "param "))
+			if len(f) == 0 || len(f) > 2 {
+				continue
+			}
+			d := paramDecl{value: value, hasDefault: true}
+			typ := ""
+			if len(f) == 2 {
+				typ = f[1]
+			}
+			typ, d.nullable = strings.CutSuffix(typ, "?")
+			if t, ok := paramTypes[typ]; ok {
+				d.typ = t
+			} else {
+				d.typ = ParamType(typ)
+			}
+			// A typed declaration without a value only declares.
+			if len(f) == 2 && value == "" {
+				d.hasDefault = false
+			}
+			decls[f[0]] = d
+		}
+	}
+	q.SQL = strings.TrimSpace(strings.Join(lines[body:], "\n"))
+
+	seen := map[string]bool{}
+	for _, t := range sqllex.Lex(q.SQL, sqllex.DialectFor(q.Driver)) {
+		if t.Kind != sqllex.Placeholder || !strings.HasPrefix(t.Text, ":") {
+			continue
+		}
+		p := t.Text[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		param := Param{Name: p, Type: Text}
+		if d, ok := decls[p]; ok {
+			param.Type, param.Nullable = d.typ, d.nullable
+			param.Default, param.HasDefault = d.value, d.hasDefault
+		}
+		q.Params = append(q.Params, param)
+	}
+	return q
+}
+
+// Bind replaces the :name placeholders with values (falling back to the
+// defaults) as SQL literals of the parameters' types. Every parameter
+// needs a value.
+func (q *Query) Bind(values map[string]string, d sqllex.Dialect) (string, error) {
+	bound := map[string]string{}
+	var missing []string
+	for _, p := range q.Params {
+		v, ok := values[p.Name]
+		switch {
+		case ok:
+		case p.HasDefault:
+			v = p.Default
+		default:
+			missing = append(missing, p.Name)
+			continue
+		}
+		lit, err := Literal(v, p, d)
+		if err != nil {
+			return "", fmt.Errorf("query %s: %w", q.Name, err)
+		}
+		bound[p.Name] = lit
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("query %s: missing parameter(s) %s", q.Name, strings.Join(missing, ", "))
+	}
+
+	var b strings.Builder
+	for _, t := range sqllex.Lex(q.SQL, d) {
+		if lit, ok := bound[strings.TrimPrefix(t.Text, ":")]; ok &&
+			t.Kind == sqllex.Placeholder && strings.HasPrefix(t.Text, ":") {
+			b.WriteString(lit)
+			continue
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String(), nil
+}
+
+// Literal writes v as a SQL literal for p: a quoted string, a bare
+// number for Number parameters, and NULL for "null" when p is nullable.
+func Literal(v string, p Param, d sqllex.Dialect) (string, error) {
+	if p.Nullable && strings.EqualFold(v, "null") {
+		return "NULL", nil
+	}
+	switch p.Type {
+	case Text, "":
+		return sqllex.QuoteString(v, d), nil
+	case Number:
+		// ParseFloat also takes hex, "Inf", "NaN" and underscores,
+		// none of which are SQL numbers.
+		if _, err := strconv.ParseFloat(v, 64); err != nil || strings.ContainsAny(v, "xXpPnNiI_") {
+			return "", fmt.Errorf("parameter %s: %q is not a number", p.Name, v)
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("parameter %s: unknown type %q (use text or number)", p.Name, p.Type)
+}
+
+// Matches reports whether q is offered for driver.
+func (q *Query) Matches(driver string) bool {
+	return q.Driver == "" || strings.EqualFold(q.Driver, driver)
+}
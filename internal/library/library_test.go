@@ -0,0 +1,190 @@
+package library
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+func TestLiteral(t *testing.T) {
+	text := Param{Name: "p", Type: Text}
+	nullableText := Param{Name: "p", Type: Text, Nullable: true}
+	number := Param{Name: "p", Type: Number}
+	nullableNumber := Param{Name: "p", Type: Number, Nullable: true}
+
+	tests := []struct {
+		name    string
+		v       string
+		p       Param
+		d       sqllex.Dialect
+		want    string
+		wantErr bool
+	}{
+		{"text", "abc", text, sqllex.Postgres, "'abc'", false},
+		{"quote doubled", "O'Brien", text, sqllex.Postgres, "'O''Brien'", false},
+		{"leading zero kept", "01234", text, sqllex.Postgres, "'01234'", false},
+		{"number-like text quoted", "42", text, sqllex.SQLite, "'42'", false},
+		{"null is a string", "null", text, sqllex.Postgres, "'null'", false},
+		{"nullable null", "NULL", nullableText, sqllex.Postgres, "NULL", false},
+		{"nullable value", "x", nullableText, sqllex.Postgres, "'x'", false},
+		{"backslash postgres", `a\`, text, sqllex.Postgres, `'a\'`, false},
+		{"backslash mysql", `abc\`, text, sqllex.MySQL, `'abc\\'`, false},
+		{"mysql breakout", `\' OR 1=1 -- `, text, sqllex.MySQL, `'\\'' OR 1=1 -- '`, false},
+		{"mssql", `it's`, text, sqllex.MSSQL, `'it''s'`, false},
+		{"untyped is text", "7", Param{Name: "p"}, sqllex.Postgres, "'7'", false},
+		{"number", "20", number, sqllex.Postgres, "20", false},
+		{"negative decimal", "-1.5e3", number, sqllex.MySQL, "-1.5e3", false},
+		{"number injection", "1; DROP TABLE t", number, sqllex.Postgres, "", true},
+		{"number hex", "0x1F", number, sqllex.Postgres, "", true},
+		{"number inf", "Inf", number, sqllex.Postgres, "", true},
+		{"number nan", "NaN", number, sqllex.Postgres, "", true},
+		{"number empty", "", number, sqllex.Postgres, "", true},
+		{"number null", "null", number, sqllex.Postgres, "", true},
+		{"nullable number null", "null", nullableNumber, sqllex.Postgres, "NULL", false},
+		{"unknown type", "x", Param{Name: "p", Type: "date"}, sqllex.Postgres, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Literal(tt.v, tt.p, tt.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Literal(%q) error = %v, wantErr %v", tt.v, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Literal(%q) = %s, want %s", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		values  map[string]string
+		d       sqllex.Dialect
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "default and value",
+			src:    "-- param limit number: 20\nSELECT * FROM t WHERE a = :a LIMIT :limit",
+			values: map[string]string{"a": "x"},
+			d:      sqllex.Postgres,
+			want:   "SELECT * FROM t WHERE a = 'x' LIMIT 20",
+		},
+		{
+			name:   "value overrides default",
+			src:    "-- param limit int: 20\nSELECT 1 LIMIT :limit",
+			values: map[string]string{"limit": "5"},
+			d:      sqllex.SQLite,
+			want:   "SELECT 1 LIMIT 5",
+		},
+		{
+			name:   "untyped parameter quoted",
+			src:    "SELECT * FROM addr WHERE zip = :zip",
+			values: map[string]string{"zip": "01234"},
+			d:      sqllex.Postgres,
+			want:   "SELECT * FROM addr WHERE zip = '01234'",
+		},
+		{
+			name:   "repeated placeholder",
+			src:    "SELECT :a, :a",
+			values: map[string]string{"a": "1"},
+			d:      sqllex.Postgres,
+			want:   "SELECT '1', '1'",
+		},
+		{
+			name:   "placeholder in string untouched",
+			src:    "SELECT ':a', :a",
+			values: map[string]string{"a": "v"},
+			d:      sqllex.Postgres,
+			want:   "SELECT ':a', 'v'",
+		},
+		{
+			name:   "postgres cast untouched",
+			src:    "SELECT :a::int",
+			values: map[string]string{"a": "1"},
+			d:      sqllex.Postgres,
+			want:   "SELECT '1'::int",
+		},
+		{
+			name:   "mysql backslash",
+			src:    "SELECT * FROM u WHERE name = :n",
+			values: map[string]string{"n": `\' OR 1=1 -- `},
+			d:      sqllex.MySQL,
+			want:   `SELECT * FROM u WHERE name = '\\'' OR 1=1 -- '`,
+		},
+		{
+			name:   "nullable",
+			src:    "-- param owner text?:\nSELECT * FROM t WHERE owner IS NOT DISTINCT FROM :owner",
+			values: map[string]string{"owner": "null"},
+			d:      sqllex.Postgres,
+			want:   "SELECT * FROM t WHERE owner IS NOT DISTINCT FROM NULL",
+		},
+		{
+			name:    "typed declaration has no default",
+			src:     "-- param owner text?:\nSELECT :owner",
+			d:       sqllex.Postgres,
+			wantErr: "missing parameter(s) owner",
+		},
+		{
+			name: "untyped empty default",
+			src:  "-- param s:\nSELECT :s",
+			d:    sqllex.Postgres,
+			want: "SELECT ''",
+		},
+		{
+			name:    "missing",
+			src:     "SELECT :a, :b",
+			values:  map[string]string{"a": "1"},
+			d:       sqllex.Postgres,
+			wantErr: "missing parameter(s) b",
+		},
+		{
+			name:    "not a number",
+			src:     "-- param limit number: 20\nSELECT 1 LIMIT :limit",
+			values:  map[string]string{"limit": "1 UNION SELECT 2"},
+			d:       sqllex.Postgres,
+			wantErr: "is not a number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("q", tt.src).Bind(tt.values, tt.d)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Bind error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bind: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Bind =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParamDecl(t *testing.T) {
+	q := Parse("q", "-- param a number?: 1\n-- param b: x\n-- param c string:\nSELECT :a, :b, :c, :d")
+	want := []Param{
+		{Name: "a", Type: Number, Nullable: true, Default: "1", HasDefault: true},
+		{Name: "b", Type: Text, Default: "x", HasDefault: true},
+		{Name: "c", Type: Text},
+		{Name: "d", Type: Text},
+	}
+	if len(q.Params) != len(want) {
+		t.Fatalf("Params = %+v, want %+v", q.Params, want)
+	}
+	for i := range want {
+		if q.Params[i] != want[i] {
+			t.Errorf("Params[%d] = %+v, want %+v", i, q.Params[i], want[i])
+		}
+	}
+	if got := q.Params[0].Decl(); got != "number?" {
+		t.Errorf("Decl = %q, want number?", got)
+	}
+}
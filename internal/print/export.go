@@ -0,0 +1,179 @@
+package print
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/blob"
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// Formats accepted by Render.
+const (
+	FormatTable = "table"
+	FormatTSV   = "tsv"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+// Render writes rows in the given format ("" means table).
+func Render(w io.Writer, rows *db.Rows, format string, opts Options) error {
+	switch format {
+	case "", FormatTable:
+		RenderTable(w, rows, opts)
+		return nil
+	case FormatTSV:
+		return RenderTSV(w, rows)
+	case FormatCSV:
+		return RenderCSV(w, rows)
+	case FormatJSON:
+		return RenderJSON(w, rows)
+	}
+	return fmt.Errorf("unknown output format %q (expected table, tsv, csv or json)", format)
+}
+
+// FormatForPath picks the format for a file by its extension: .csv,
+// .tsv, .json, anything else a text table.
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv":
+		return FormatTSV
+	case ".json":
+		return FormatJSON
+	}
+	return FormatTable
+}
+
+// cell returns column i of r, nil when the row is short.
+func cell(r db.Row, i int) any {
+	if i < len(r) {
+		return r[i]
+	}
+	return nil
+}
+
+// exportText is the text of v in TSV and CSV exports: cellfmt.Text,
+// except that binary values are written in full, as \x and hex (the
+// PostgreSQL bytea format, which COPY reads back), not summarized.
+func exportText(v any) string {
+	if b, ok := v.([]byte); ok && !blob.IsText(b) {
+		return `\x` + hex.EncodeToString(b)
+	}
+	return cellfmt.Text(v)
+}
+
+// tsvEscaper uses the PostgreSQL COPY text conventions.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// RenderTSV writes a header line and one line per row. NULL is written
+// as \N, so it stays distinct from the empty string; binary values as
+// \\x and hex.
+func RenderTSV(w io.Writer, rows *db.Rows) error {
+	fields := make([]string, len(rows.Columns))
+	for i, c := range rows.Columns {
+		fields[i] = tsvEscaper.Replace(c.Name)
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+		return err
+	}
+	for _, r := range rows.Data {
+		for i := range rows.Columns {
+			v := cell(r, i)
+			if v == nil {
+				fields[i] = `\N`
+				continue
+			}
+			fields[i] = tsvEscaper.Replace(exportText(v))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderCSV writes RFC 4180 CSV. NULL is an empty unquoted field and the
+// empty string is "", the convention of PostgreSQL's COPY … CSV; binary
+// values are \x and hex.
+func RenderCSV(w io.Writer, rows *db.Rows) error {
+	fields := make([]string, len(rows.Columns))
+	for i, c := range rows.Columns {
+		fields[i] = csvQuote(c.Name, false)
+	}
+	if _, err := io.WriteString(w, strings.Join(fields, ",")+"\r\n"); err != nil {
+		return err
+	}
+	for _, r := range rows.Data {
+		for i := range rows.Columns {
+			v := cell(r, i)
+			if v == nil {
+				fields[i] = ""
+				continue
+			}
+			fields[i] = csvQuote(exportText(v), true)
+		}
+		if _, err := io.WriteString(w, strings.Join(fields, ",")+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func csvQuote(s string, quoteEmpty bool) string {
+	if (s == "" && quoteEmpty) || strings.ContainsAny(s, ",\"\r\n") || strings.TrimSpace(s) != s {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return s
+}
+
+// RenderJSON writes an array of objects with keys in column order. NULL
+// is null; binary values are base64 strings.
+func RenderJSON(w io.Writer, rows *db.Rows) error {
+	keys := make([][]byte, len(rows.Columns))
+	for i, c := range rows.Columns {
+		keys[i], _ = json.Marshal(c.Name)
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	for n, r := range rows.Data {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i := range rows.Columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.Write(keys[i])
+			b.WriteString(": ")
+			b.Write(jsonValue(cell(r, i)))
+		}
+		b.WriteString("}")
+	}
+	if len(rows.Data) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func jsonValue(v any) []byte {
+	if t, ok := v.([]byte); ok && blob.IsText(t) {
+		v = string(t)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		// NaN/Inf and odd driver types: fall back to text.
+		out, _ = json.Marshal(cellfmt.Text(v))
+	}
+	return out
+}
@@ -3,14 +3,16 @@ package print
 import (
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
 	"github.com/bgunnarsson/binsql/internal/db"
 )
 
 type Options struct {
-	MaxWidth int // max width for each column, 0 = no limit
+	MaxWidth int  // max width for each column, 0 = no limit
+	Color    bool // style NULL and empty values with ANSI escapes
 }
 
 func RenderTable(w io.Writer, rows *db.Rows, opts Options) {
@@ -27,13 +29,13 @@ func RenderTable(w io.Writer, rows *db.Rows, opts Options) {
 	// compute widths
 	widths := make([]int, cols)
 	for i, col := range rows.Columns {
-		widths[i] = len(col.Name)
+		widths[i] = utf8.RuneCountInString(col.Name)
 	}
 
 	for _, r := range rows.Data {
 		for i, cell := range r {
-			s := formatCell(cell)
-			if l := len(s); l > widths[i] {
+			s, _ := cellfmt.Format(cell)
+			if l := utf8.RuneCountInString(s); l > widths[i] {
 				if l > opts.MaxWidth {
 					l = opts.MaxWidth
 				}
@@ -53,13 +55,16 @@ func RenderTable(w io.Writer, rows *db.Rows, opts Options) {
 		return b.String()
 	}
 
-	writeRow := func(cells []string) {
+	writeRow := func(cells []string, kinds []cellfmt.Kind) {
 		var b strings.Builder
 		b.WriteString("|")
 		for i, c := range cells {
-			cut := truncate(c, widths[i])
+			cut := padRight(truncate(c, widths[i]), widths[i])
+			if opts.Color && kinds != nil {
+				cut = styled(cut, kinds[i])
+			}
 			b.WriteString(" ")
-			b.WriteString(padRight(cut, widths[i]))
+			b.WriteString(cut)
 			b.WriteString(" |")
 		}
 		fmt.Fprintln(w, b.String())
@@ -71,67 +76,48 @@ func RenderTable(w io.Writer, rows *db.Rows, opts Options) {
 	for i, col := range rows.Columns {
 		header[i] = col.Name
 	}
-	writeRow(header)
+	writeRow(header, nil)
 	fmt.Fprintln(w, sep("="))
 
 	// data
 	for _, r := range rows.Data {
 		cells := make([]string, cols)
+		kinds := make([]cellfmt.Kind, cols)
 		for i, cell := range r {
-			cells[i] = formatCell(cell)
+			cells[i], kinds[i] = cellfmt.Format(cell)
 		}
-		writeRow(cells)
+		writeRow(cells, kinds)
 	}
 	fmt.Fprintln(w, sep("-"))
 }
 
-func formatCell(v any) string {
-	if v == nil {
-		return "NULL"
-	}
-	switch t := v.(type) {
-	case []byte:
-		// heuristic: treat as string if printable, else show len
-		s := string(t)
-		if isPrintable(s) {
-			return s
-		}
-		return fmt.Sprintf("<blob %d bytes>", len(t))
-	case int64:
-		return strconv.FormatInt(t, 10)
-	case float64:
-		return strconv.FormatFloat(t, 'f', -1, 64)
-	default:
-		return fmt.Sprint(t)
+// styled wraps NULL (dim italic) and empty/blank values (dim) in ANSI
+// escapes; padding is included so the borders stay aligned.
+func styled(s string, kind cellfmt.Kind) string {
+	switch kind {
+	case cellfmt.Null:
+		return "\x1b[2;3m" + s + "\x1b[0m"
+	case cellfmt.Empty, cellfmt.Blank, cellfmt.Binary:
+		return "\x1b[2m" + s + "\x1b[0m"
 	}
-}
-
-func isPrintable(s string) bool {
-	for _, r := range s {
-		if r < 32 && r != '\n' && r != '\t' {
-			return false
-		}
-	}
-	return true
+	return s
 }
 
 func padRight(s string, w int) string {
-	if len(s) >= w {
+	n := utf8.RuneCountInString(s)
+	if n >= w {
 		return s
 	}
-	return s + strings.Repeat(" ", w-len(s))
+	return s + strings.Repeat(" ", w-n)
 }
 
 func truncate(s string, w int) string {
-	if len(s) <= w {
+	r := []rune(s)
+	if len(r) <= w {
 		return s
 	}
-	if w <= 1 {
-		return s[:w]
+	if w <= 2 {
+		return string(r[:w])
 	}
-	if w == 2 {
-		return s[:2]
-	}
-	return s[:w-3] + "..."
+	return string(r[:w-3]) + "..."
 }
-
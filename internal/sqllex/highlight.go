@@ -0,0 +1,72 @@
+package sqllex
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Colors maps token kinds to colors ("#RRGGBB" or a color name) for
+// Tview. They start as Catppuccin Mocha accents; the TUI replaces them
+// with its theme's. Kinds without an entry keep the default text color.
+var Colors = map[Kind]string{
+	Keyword:     "#CBA6F7", // mauve
+	Function:    "#89B4FA", // blue
+	String:      "#A6E3A1", // green
+	Number:      "#FAB387", // peach
+	Comment:     "#7F849C", // overlay1
+	Placeholder: "#94E2D5", // teal
+	QuotedIdent: "#F9E2AF", // yellow
+	Operator:    "#89DCEB", // sky
+}
+
+// ANSIColors maps token kinds to SGR parameters for ANSI. They start as
+// plain 16-color codes, which look right in any terminal; --echo
+// replaces them with the configured theme's (see ui.EchoColors).
+var ANSIColors = map[Kind]string{
+	Keyword:     "1;35",
+	Function:    "34",
+	String:      "32",
+	Number:      "33",
+	Comment:     "90",
+	Placeholder: "36",
+	QuotedIdent: "93",
+	Operator:    "36",
+}
+
+// Tview renders src with tview color tags.
+func Tview(src string, d Dialect) string {
+	var b strings.Builder
+	b.Grow(len(src) * 2)
+	for _, t := range Lex(src, d) {
+		text := escapeTview(t.Text)
+		if c, ok := Colors[t.Kind]; ok {
+			b.WriteString("[" + c + "]" + text + "[-]")
+		} else {
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// ANSI renders src with ANSI escape sequences for terminal output.
+func ANSI(src string, d Dialect) string {
+	var b strings.Builder
+	b.Grow(len(src) * 2)
+	for _, t := range Lex(src, d) {
+		if c, ok := ANSIColors[t.Kind]; ok {
+			b.WriteString("\x1b[" + c + "m" + t.Text + "\x1b[0m")
+		} else {
+			b.WriteString(t.Text)
+		}
+	}
+	return b.String()
+}
+
+// tviewEscape is the pattern tview.Escape uses; mirrored here so this
+// package does not depend on tview. Every token that can contain a
+// bracket is colored, so escaping per token is enough.
+var tviewEscape = regexp.MustCompile(`(\[[a-zA-Z0-9_,;: \-\."#]+\[*)\]`)
+
+func escapeTview(s string) string {
+	return tviewEscape.ReplaceAllString(s, "$1[]")
+}
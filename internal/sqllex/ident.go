@@ -0,0 +1,36 @@
+package sqllex
+
+import "strings"
+
+// QuoteIdent quotes name as an identifier of dialect d: "name" for
+// postgres, sqlite and the generic dialect, `name` for mysql and [name]
+// for mssql. It keeps its case and may be a reserved word.
+func QuoteIdent(name string, d Dialect) string {
+	switch d {
+	case MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case MSSQL:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteString writes v as a string literal of dialect d. MySQL reads
+// backslash escapes in strings by default, so there backslashes are
+// doubled too.
+func QuoteString(v string, d Dialect) string {
+	if d == MySQL {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+// QuoteQualified quotes each dot-separated part of a possibly qualified
+// name ("schema.table", "db.schema.table") with QuoteIdent.
+func QuoteQualified(name string, d Dialect) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = QuoteIdent(p, d)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,152 @@
+package sqllex
+
+import (
+	"sort"
+	"strings"
+)
+
+// Dialect selects dialect-specific lexing rules and keywords.
+type Dialect string
+
+const (
+	Generic  Dialect = ""
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+	MSSQL    Dialect = "mssql"
+	MySQL    Dialect = "mysql"
+)
+
+// DialectFor maps a driver name ("postgres", "mssql", …) to a dialect.
+func DialectFor(driver string) Dialect {
+	switch Dialect(strings.ToLower(driver)) {
+	case SQLite, Postgres, MSSQL, MySQL:
+		return Dialect(strings.ToLower(driver))
+	}
+	return Generic
+}
+
+var commonKeywords = words(`
+ADD ALL ALTER AND ANY AS ASC BEGIN BETWEEN BY CASCADE CASE CAST CHECK
+COLUMN COMMIT CONSTRAINT CREATE CROSS CURRENT_DATE CURRENT_TIME
+CURRENT_TIMESTAMP DATABASE DEFAULT DELETE DESC DISTINCT DROP ELSE END
+ESCAPE EXCEPT EXISTS EXPLAIN FALSE FETCH FOREIGN FROM FULL GRANT GROUP
+HAVING IF IN INDEX INNER INSERT INTERSECT INTO IS JOIN KEY LEFT LIKE LIMIT
+NATURAL NOT NULL NULLS OFFSET ON OR ORDER OUTER OVER PARTITION PRIMARY
+REFERENCES REVOKE RIGHT ROLLBACK ROW ROWS SCHEMA SELECT SET TABLE THEN TO
+TRANSACTION TRIGGER TRUE TRUNCATE UNION UNIQUE UPDATE USING VALUES VIEW
+WHEN WHERE WINDOW WITH
+INT INTEGER SMALLINT BIGINT DECIMAL NUMERIC REAL FLOAT DOUBLE PRECISION
+CHAR VARCHAR TEXT DATE TIME TIMESTAMP BOOLEAN BLOB
+`)
+
+var dialectKeywords = map[Dialect]map[string]bool{
+	Postgres: words(`
+ILIKE SIMILAR RETURNING LATERAL ONLY VACUUM ANALYZE VERBOSE CONCURRENTLY
+MATERIALIZED REFRESH LISTEN NOTIFY UNLISTEN DO CONFLICT NOTHING EXTENSION
+SEQUENCE SERIAL BIGSERIAL JSONB JSON UUID BYTEA TIMESTAMPTZ INTERVAL ARRAY
+INHERITS TABLESPACE DOMAIN TYPE ENUM FILTER WITHIN ORDINALITY RECURSIVE
+`),
+	MySQL: words(`
+AUTO_INCREMENT ENGINE CHARSET COLLATE UNSIGNED ZEROFILL REPLACE IGNORE
+DUPLICATE STRAIGHT_JOIN SQL_CALC_FOUND_ROWS SHOW DESCRIBE USE TINYINT
+MEDIUMINT TINYTEXT MEDIUMTEXT LONGTEXT TINYBLOB MEDIUMBLOB LONGBLOB ENUM
+DATETIME JSON REGEXP RLIKE DUAL LOCK UNLOCK TABLES PROCEDURE RECURSIVE
+`),
+	MSSQL: words(`
+TOP NVARCHAR NCHAR NTEXT DATETIME2 DATETIMEOFFSET UNIQUEIDENTIFIER BIT
+MONEY IMAGE VARBINARY IDENTITY OUTPUT MERGE MATCHED TRY CATCH THROW
+DECLARE EXEC EXECUTE PROCEDURE PROC GO NOLOCK APPLY PIVOT UNPIVOT PERCENT
+TIES OFFSET NEXT ONLY CLUSTERED NONCLUSTERED INCLUDE SHOWPLAN_XML
+`),
+	SQLite: words(`
+AUTOINCREMENT PRAGMA VACUUM ATTACH DETACH GLOB REPLACE ABORT FAIL IGNORE
+CONFLICT WITHOUT ROWID STRICT VIRTUAL RETURNING RECURSIVE
+`),
+}
+
+// functionKeywords are keywords that double as functions (COUNT(...)),
+// colored as functions when followed by "(".
+var functionKeywords = words(`
+COUNT SUM AVG MIN MAX COALESCE NULLIF CAST CONVERT LEFT RIGHT REPLACE
+EXISTS ANY ROW FILTER
+`)
+
+// IsKeyword reports whether word is a keyword in d (case-insensitive).
+func IsKeyword(word string, d Dialect) bool {
+	u := strings.ToUpper(word)
+	if commonKeywords[u] {
+		return true
+	}
+	return dialectKeywords[d][u]
+}
+
+// Keywords returns the sorted keyword list for d.
+func Keywords(d Dialect) []string {
+	var out []string
+	for k := range commonKeywords {
+		out = append(out, k)
+	}
+	for k := range dialectKeywords[d] {
+		if !commonKeywords[k] {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isFunctionKeyword(word string) bool {
+	return functionKeywords[strings.ToUpper(word)]
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var commonFunctions = words(`
+ABS AVG CAST COALESCE CONCAT COUNT LENGTH LOWER MAX MIN NULLIF REPLACE
+ROUND SUBSTRING SUM TRIM UPPER ROW_NUMBER RANK DENSE_RANK LAG LEAD
+`)
+
+var dialectFunctions = map[Dialect]map[string]bool{
+	Postgres: words(`
+NOW CURRENT_SETTING DATE_TRUNC EXTRACT TO_CHAR TO_DATE TO_TIMESTAMP
+STRING_AGG ARRAY_AGG ARRAY_LENGTH UNNEST JSONB_BUILD_OBJECT JSON_AGG
+JSONB_AGG JSONB_EXTRACT_PATH_TEXT JSONB_ARRAY_ELEMENTS GENERATE_SERIES
+REGEXP_REPLACE SPLIT_PART GREATEST LEAST PG_SIZE_PRETTY
+PG_TOTAL_RELATION_SIZE PG_NOTIFY GEN_RANDOM_UUID AGE
+`),
+	MySQL: words(`
+NOW CURDATE DATE_FORMAT DATE_ADD DATE_SUB DATEDIFF IFNULL IF
+GROUP_CONCAT JSON_EXTRACT JSON_UNQUOTE JSON_OBJECT JSON_ARRAYAGG
+FROM_UNIXTIME UNIX_TIMESTAMP UUID GREATEST LEAST FIND_IN_SET
+`),
+	MSSQL: words(`
+GETDATE GETUTCDATE SYSDATETIME DATEADD DATEDIFF DATEPART FORMAT ISNULL
+IIF LEN CHARINDEX STRING_AGG JSON_VALUE JSON_QUERY OPENJSON NEWID
+SCOPE_IDENTITY OBJECT_ID CONVERT TRY_CONVERT TRY_CAST
+`),
+	SQLite: words(`
+DATE DATETIME JULIANDAY STRFTIME IFNULL INSTR GROUP_CONCAT JSON_EXTRACT
+JSON_OBJECT JSON_GROUP_ARRAY RANDOM TYPEOF LAST_INSERT_ROWID PRINTF
+`),
+}
+
+// Functions returns the sorted list of well-known functions for d.
+func Functions(d Dialect) []string {
+	var out []string
+	for k := range commonFunctions {
+		out = append(out, k)
+	}
+	for k := range dialectFunctions[d] {
+		if !commonFunctions[k] {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,281 @@
+// Package sqllex is a small, forgiving SQL tokenizer used for syntax
+// highlighting. It never fails: unterminated strings and comments simply
+// run to the end of the input, which is what you want while typing.
+package sqllex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	Whitespace Kind = iota
+	Keyword
+	Function // identifier directly followed by "("
+	Ident
+	QuotedIdent
+	String
+	Number
+	Comment
+	Placeholder
+	Operator
+	Punct
+)
+
+type Token struct {
+	Kind  Kind
+	Text  string
+	Start int // byte offset into the source
+	End   int
+}
+
+// Lex splits src into tokens. Concatenating Text of all tokens yields src.
+func Lex(src string, d Dialect) []Token {
+	l := &lexer{src: src, d: d}
+	for l.pos < len(src) {
+		l.next()
+	}
+	return l.toks
+}
+
+type lexer struct {
+	src  string
+	pos  int
+	d    Dialect
+	toks []Token
+}
+
+func (l *lexer) emit(k Kind, end int) {
+	if end > len(l.src) {
+		end = len(l.src)
+	}
+	l.toks = append(l.toks, Token{Kind: k, Text: l.src[l.pos:end], Start: l.pos, End: end})
+	l.pos = end
+}
+
+func (l *lexer) peek(off int) byte {
+	if l.pos+off < len(l.src) {
+		return l.src[l.pos+off]
+	}
+	return 0
+}
+
+func (l *lexer) next() {
+	c := l.src[l.pos]
+	rest := l.src[l.pos:]
+
+	switch {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		end := l.pos
+		for end < len(l.src) && strings.IndexByte(" \t\n\r", l.src[end]) >= 0 {
+			end++
+		}
+		l.emit(Whitespace, end)
+
+	case strings.HasPrefix(rest, "--") || (c == '#' && l.d == MySQL):
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+		l.emit(Comment, l.pos+end)
+
+	case strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			l.emit(Comment, len(l.src))
+		} else {
+			l.emit(Comment, l.pos+2+end+2)
+		}
+
+	case c == '\'':
+		// MySQL escapes with backslashes by default (no NO_BACKSLASH_ESCAPES).
+		l.emit(String, l.quoted(l.pos, '\'', l.d == MySQL))
+
+	case (c == 'E' || c == 'e') && l.peek(1) == '\'' && l.d == Postgres:
+		l.emit(String, l.quoted(l.pos+1, '\'', true))
+
+	case (c == 'N' || c == 'n' || c == 'X' || c == 'x' || c == 'B' || c == 'b') && l.peek(1) == '\'':
+		l.emit(String, l.quoted(l.pos+1, '\'', l.d == MySQL))
+
+	case c == '"':
+		if l.d == MySQL {
+			l.emit(String, l.quoted(l.pos, '"', true))
+		} else {
+			l.emit(QuotedIdent, l.quoted(l.pos, '"', false))
+		}
+
+	case c == '`' && (l.d == MySQL || l.d == SQLite):
+		l.emit(QuotedIdent, l.quoted(l.pos, '`', false))
+
+	case c == '[' && (l.d == MSSQL || l.d == SQLite):
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			l.emit(QuotedIdent, len(l.src))
+		} else {
+			l.emit(QuotedIdent, l.pos+end+1)
+		}
+
+	case c == '$' && l.d == Postgres:
+		if tag, ok := dollarTag(rest); ok {
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				l.emit(String, len(l.src))
+			} else {
+				l.emit(String, l.pos+len(tag)+end+len(tag))
+			}
+			return
+		}
+		if isDigit(l.peek(1)) {
+			l.emit(Placeholder, l.scanWhile(l.pos+1, isDigit))
+			return
+		}
+		l.emit(Operator, l.pos+1)
+
+	case c == '?':
+		l.emit(Placeholder, l.pos+1)
+
+	case c == '@' && (l.d == MSSQL || l.d == MySQL || l.d == SQLite):
+		// @param (mssql/sqlite), @var / @@global (mysql)
+		end := l.pos + 1
+		if l.peek(1) == '@' {
+			end++
+		}
+		l.emit(Placeholder, l.scanWhile(end, isIdentByte))
+
+	case c == ':' && l.peek(1) != ':' && isIdentStart(l.peek(1)) && l.prevNotColon():
+		l.emit(Placeholder, l.scanWhile(l.pos+1, isIdentByte))
+
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.emit(Number, l.number())
+
+	case isIdentStart(c) || c >= utf8.RuneSelf:
+		end := l.pos
+		for end < len(l.src) {
+			r, size := utf8.DecodeRuneInString(l.src[end:])
+			if r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				end += size
+				continue
+			}
+			break
+		}
+		if end == l.pos {
+			// lone non-letter rune
+			_, size := utf8.DecodeRuneInString(rest)
+			l.emit(Operator, l.pos+size)
+			return
+		}
+		word := l.src[l.pos:end]
+		kind := Ident
+		if IsKeyword(word, l.d) {
+			kind = Keyword
+		}
+		if kind == Ident || isFunctionKeyword(word) {
+			after := end
+			for after < len(l.src) && (l.src[after] == ' ' || l.src[after] == '\t') {
+				after++
+			}
+			if after < len(l.src) && l.src[after] == '(' {
+				kind = Function
+			}
+		}
+		l.emit(kind, end)
+
+	case strings.IndexByte("(),;.", c) >= 0:
+		l.emit(Punct, l.pos+1)
+
+	default:
+		for _, op := range []string{"->>", "->", "::", "<=", ">=", "<>", "!=", "||", "<<", ">>", "#>>", "#>", "@>", "<@", "~*", "!~"} {
+			if strings.HasPrefix(rest, op) {
+				l.emit(Operator, l.pos+len(op))
+				return
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		l.emit(Operator, l.pos+size)
+	}
+}
+
+// quoted scans a quoted literal starting at the opening quote at start
+// and returns the end offset. Doubled quotes always escape; backslashes
+// escape too when backslash is set.
+func (l *lexer) quoted(start int, q byte, backslash bool) int {
+	i := start + 1
+	for i < len(l.src) {
+		switch l.src[i] {
+		case '\\':
+			if backslash {
+				i += 2
+				continue
+			}
+		case q:
+			if i+1 < len(l.src) && l.src[i+1] == q {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return len(l.src)
+}
+
+func (l *lexer) number() int {
+	i := l.pos
+	if strings.HasPrefix(l.src[i:], "0x") || strings.HasPrefix(l.src[i:], "0X") {
+		return l.scanWhile(i+2, isHexDigit)
+	}
+	i = l.scanWhile(i, isDigit)
+	if i < len(l.src) && l.src[i] == '.' {
+		i = l.scanWhile(i+1, isDigit)
+	}
+	if i < len(l.src) && (l.src[i] == 'e' || l.src[i] == 'E') {
+		j := i + 1
+		if j < len(l.src) && (l.src[j] == '+' || l.src[j] == '-') {
+			j++
+		}
+		if j < len(l.src) && isDigit(l.src[j]) {
+			i = l.scanWhile(j, isDigit)
+		}
+	}
+	return i
+}
+
+func (l *lexer) scanWhile(i int, ok func(byte) bool) int {
+	for i < len(l.src) && ok(l.src[i]) {
+		i++
+	}
+	return i
+}
+
+// prevNotColon keeps "::type" casts from lexing as ":type" placeholders.
+func (l *lexer) prevNotColon() bool {
+	return l.pos == 0 || l.src[l.pos-1] != ':'
+}
+
+// dollarTag matches a postgres dollar-quote opener: $$ or $tag$.
+func dollarTag(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '$' {
+		return "", false
+	}
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1], true
+		case isIdentByte(s[i]) && !(i == 1 && isDigit(s[i])):
+			continue
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isHexDigit(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+func isIdentByte(c byte) bool { return isIdentStart(c) || isDigit(c) }
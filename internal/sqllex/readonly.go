@@ -0,0 +1,78 @@
+package sqllex
+
+import "strings"
+
+// writeWords make a SELECT or WITH statement change data: SELECT … INTO
+// creates a table (or a file, in MySQL), postgres allows INSERT,
+// UPDATE, DELETE and MERGE inside WITH, and FOR UPDATE locks rows.
+// UPDLOCK, XLOCK and HOLDLOCK are the mssql table hints that do the
+// same.
+var writeWords = words(`INTO INSERT UPDATE DELETE MERGE UPDLOCK XLOCK HOLDLOCK TABLOCKX`)
+
+// writeFuncs are functions with side effects that a SELECT can call:
+// ending or signalling other sessions, moving sequences, advisory and
+// named locks, large objects, settings, and queries run on another
+// server.
+var writeFuncs = words(`
+PG_TERMINATE_BACKEND PG_CANCEL_BACKEND PG_RELOAD_CONF PG_ROTATE_LOGFILE
+PG_PROMOTE PG_SWITCH_WAL PG_CREATE_RESTORE_POINT PG_NOTIFY SET_CONFIG
+SETVAL NEXTVAL LO_IMPORT LO_EXPORT LO_UNLINK LO_CREATE LO_FROM_BYTEA
+LO_PUT GET_LOCK RELEASE_LOCK RELEASE_ALL_LOCKS OPENROWSET OPENQUERY
+OPENDATASOURCE
+`)
+
+// writeFuncPrefixes name families of such functions: postgres advisory
+// locks and dblink, and mssql extended and system procedures.
+var writeFuncPrefixes = []string{"PG_ADVISORY", "PG_TRY_ADVISORY", "DBLINK", "XP_", "SP_"}
+
+// IsSelect reports whether src is a single SELECT statement, possibly
+// behind WITH, that only reads: no INTO, no data-modifying WITH, no
+// row locks (FOR UPDATE/SHARE, LOCK IN SHARE MODE) and no call to a
+// known side-effect function such as setval or pg_terminate_backend.
+// It cannot see inside user-defined functions; adapters that implement
+// db.ReadOnlySetter back it on the server.
+func IsSelect(src string, d Dialect) bool {
+	stmts := Split(src, d)
+	if len(stmts) != 1 {
+		return false
+	}
+	first, prev := "", ""
+	for _, t := range Lex(stmts[0], d) {
+		if t.Kind == Whitespace || t.Kind == Comment {
+			continue
+		}
+		word := strings.ToUpper(t.Text)
+		if first == "" {
+			first = word
+		}
+		switch t.Kind {
+		case Keyword, Ident:
+			if writeWords[word] {
+				return false
+			}
+			// FOR SHARE, FOR NO KEY UPDATE, FOR KEY SHARE, LOCK IN SHARE MODE
+			if (prev == "FOR" && (word == "SHARE" || word == "NO" || word == "KEY")) ||
+				(prev == "LOCK" && word == "IN") {
+				return false
+			}
+		case Function:
+			if writeFunc(word) {
+				return false
+			}
+		}
+		prev = word
+	}
+	return first == "SELECT" || first == "WITH"
+}
+
+func writeFunc(name string) bool {
+	if writeFuncs[name] {
+		return true
+	}
+	for _, p := range writeFuncPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package sqllex
+
+import "strings"
+
+// Split cuts src into statements at semicolons outside strings, comments
+// and quoted identifiers. The semicolons are dropped, and so are pieces
+// holding nothing but whitespace and comments.
+func Split(src string, d Dialect) []string {
+	var out []string
+	start, code := 0, false
+	flush := func(end int) {
+		if code {
+			out = append(out, strings.TrimSpace(src[start:end]))
+		}
+		start, code = end, false
+	}
+	for _, t := range Lex(src, d) {
+		switch {
+		case t.Kind == Punct && t.Text == ";":
+			flush(t.Start)
+			start = t.End
+		case t.Kind != Whitespace && t.Kind != Comment:
+			code = true
+		}
+	}
+	flush(len(src))
+	return out
+}
@@ -0,0 +1,194 @@
+package sqllex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var kindNames = map[Kind]string{
+	Keyword:     "kw",
+	Function:    "fn",
+	Ident:       "id",
+	QuotedIdent: "qid",
+	String:      "str",
+	Number:      "num",
+	Comment:     "com",
+	Placeholder: "ph",
+	Operator:    "op",
+	Punct:       "p",
+}
+
+// tokens renders the tokens of src other than whitespace as kind:text.
+func tokens(src string, d Dialect) []string {
+	var out []string
+	for _, t := range Lex(src, d) {
+		if t.Kind != Whitespace {
+			out = append(out, kindNames[t.Kind]+":"+t.Text)
+		}
+	}
+	return out
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    Dialect
+		want []string
+	}{
+		{"dollar quote", "SELECT $$it's; here$$", Postgres,
+			[]string{"kw:SELECT", "str:$$it's; here$$"}},
+		{"tagged dollar quote", "$fn$ a $$ b $fn$;", Postgres,
+			[]string{"str:$fn$ a $$ b $fn$", "p:;"}},
+		{"unterminated dollar quote", "$$ open", Postgres,
+			[]string{"str:$$ open"}},
+		{"positional placeholder", "$1", Postgres, []string{"ph:$1"}},
+		{"dollar outside postgres", "$$", Generic, []string{"op:$", "op:$"}},
+		{"cast", "x::int", Postgres, []string{"id:x", "op:::", "kw:int"}},
+		{"named placeholder", "id = :id", Postgres,
+			[]string{"id:id", "op:=", "ph::id"}},
+		{"cast then placeholder", "a::text || :b", Postgres,
+			[]string{"id:a", "op:::", "kw:text", "op:||", "ph::b"}},
+		{"line comment", "1 -- a; b\n2", Generic,
+			[]string{"num:1", "com:-- a; b", "num:2"}},
+		{"block comment", "/* ; */x", Generic, []string{"com:/* ; */", "id:x"}},
+		{"mysql hash comment", "# x;\n1", MySQL, []string{"com:# x;", "num:1"}},
+		{"doubled quote", "'a'';b'", Generic, []string{"str:'a'';b'"}},
+		{"escape string", `E'a\';b'`, Postgres, []string{`str:E'a\';b'`}},
+		{"mysql backslash", `'a\';b'`, MySQL, []string{`str:'a\';b'`}},
+		{"mysql double quotes", `"a"`, MySQL, []string{`str:"a"`}},
+		{"quoted ident", `"a;b"`, Postgres, []string{`qid:"a;b"`}},
+		{"mssql brackets", "[a;b]", MSSQL, []string{"qid:[a;b]"}},
+		{"function", "count (x)", Generic,
+			[]string{"fn:count", "p:(", "id:x", "p:)"}},
+		{"hex number", "0x1F", Generic, []string{"num:0x1F"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokens(tt.src, tt.d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lex(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexRoundTrip(t *testing.T) {
+	for _, src := range []string{
+		"SELECT 'a' || $$b$$ FROM t -- c\n/* d */;",
+		"unterminated 'string",
+		"/* unterminated comment",
+		"héllo wörld; ünïcode",
+	} {
+		var b strings.Builder
+		for _, tok := range Lex(src, Postgres) {
+			b.WriteString(tok.Text)
+		}
+		if b.String() != src {
+			t.Errorf("tokens of %q join to %q", src, b.String())
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    Dialect
+		want []string
+	}{
+		{"single", "SELECT 1", Generic, []string{"SELECT 1"}},
+		{"trailing semicolon", "SELECT 1;", Generic, []string{"SELECT 1"}},
+		{"multiple", "SELECT 1; SELECT 2;\nSELECT 3", Generic,
+			[]string{"SELECT 1", "SELECT 2", "SELECT 3"}},
+		{"empty pieces dropped", ";; SELECT 1 ;  ;", Generic, []string{"SELECT 1"}},
+		{"only comments", "-- nothing\n/* here */;", Generic, nil},
+		{"semicolon in string", "SELECT 'a;b'; SELECT 2", Generic,
+			[]string{"SELECT 'a;b'", "SELECT 2"}},
+		{"semicolon in comment", "SELECT 1 -- x; y\n; SELECT 2", Generic,
+			[]string{"SELECT 1 -- x; y", "SELECT 2"}},
+		{"semicolon in block comment", "SELECT /* ; */ 1", Generic,
+			[]string{"SELECT /* ; */ 1"}},
+		{"semicolon in dollar quote", "DO $$ BEGIN NULL; END $$; SELECT 1", Postgres,
+			[]string{"DO $$ BEGIN NULL; END $$", "SELECT 1"}},
+		{"semicolon in quoted ident", `SELECT "a;b" FROM t`, Postgres,
+			[]string{`SELECT "a;b" FROM t`}},
+		{"mysql escaped quote", `SELECT 'a\';b'; SELECT 2`, MySQL,
+			[]string{`SELECT 'a\';b'`, "SELECT 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.src, tt.d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSelect(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    Dialect
+		want bool
+	}{
+		{"select", "SELECT * FROM t", Postgres, true},
+		{"lowercase", "select 1;", Postgres, true},
+		{"leading comment", "-- why\nSELECT 1", Postgres, true},
+		{"with select", "WITH x AS (SELECT 1) SELECT * FROM x", Postgres, true},
+		{"with delete", "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", Postgres, false},
+		{"with insert", "WITH i AS (INSERT INTO t VALUES (1) RETURNING id) SELECT id FROM i", Postgres, false},
+		{"select into", "SELECT * INTO copy FROM t", Postgres, false},
+		{"mysql into outfile", "SELECT * FROM t INTO OUTFILE '/tmp/x'", MySQL, false},
+		{"write word in string", "SELECT 'DELETE FROM t; INSERT'", Postgres, true},
+		{"write word in comment", "SELECT 1 /* UPDATE t */", Postgres, true},
+		{"write word as quoted ident", `SELECT "update" FROM t`, Postgres, true},
+		{"multiple statements", "SELECT 1; SELECT 2", Postgres, false},
+		{"select then write", "SELECT 1; DELETE FROM t", Postgres, false},
+		{"semicolon in string only", "SELECT ';'", Postgres, true},
+		{"insert", "INSERT INTO t VALUES (1)", Postgres, false},
+		{"empty", "", Postgres, false},
+		{"for update", "SELECT * FROM t FOR UPDATE", Postgres, false},
+		{"for share", "SELECT * FROM t FOR SHARE", Postgres, false},
+		{"for no key update", "SELECT * FROM t FOR NO KEY UPDATE", Postgres, false},
+		{"for key share", "SELECT * FROM t FOR KEY SHARE", Postgres, false},
+		{"lock in share mode", "SELECT * FROM t LOCK IN SHARE MODE", MySQL, false},
+		{"mssql updlock", "SELECT * FROM t WITH (UPDLOCK)", MSSQL, false},
+		{"setval", "SELECT setval('s', 1)", Postgres, false},
+		{"nextval", "SELECT nextval('s')", Postgres, false},
+		{"terminate backend", "SELECT pg_catalog.pg_terminate_backend(42)", Postgres, false},
+		{"advisory lock", "SELECT pg_advisory_lock(1)", Postgres, false},
+		{"dblink", "SELECT * FROM dblink('db', 'DROP TABLE t') AS x(a int)", Postgres, false},
+		{"openrowset", "SELECT * FROM OPENROWSET('SQLNCLI', 'x', 'y')", MSSQL, false},
+		{"xp proc", "SELECT master.dbo.xp_fileexist('c:\\x')", MSSQL, false},
+		{"function name as column", "SELECT setval FROM t", Postgres, true},
+		{"ordinary function", "SELECT count(*), lower(name) FROM t", Postgres, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSelect(tt.src, tt.d); got != tt.want {
+				t.Errorf("IsSelect(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{QuoteString(`O'Brien`, Postgres), `'O''Brien'`},
+		{QuoteString(`a\b`, Postgres), `'a\b'`},
+		{QuoteString(`a\'b`, MySQL), `'a\\''b'`},
+		{QuoteIdent(`a"b`, Postgres), `"a""b"`},
+		{QuoteIdent("a`b", MySQL), "`a``b`"},
+		{QuoteIdent("a]b", MSSQL), "[a]]b]"},
+		{QuoteQualified("public.my table", Postgres), `"public"."my table"`},
+		{QuoteQualified("dbo.t", MSSQL), "[dbo].[t]"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %s, want %s", tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+// Package sshtunnel forwards a local port to a database that is only
+// reachable through an SSH jump host (bastion).
+package sshtunnel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// dialTimeout bounds the SSH handshake with the jump host.
+const dialTimeout = 10 * time.Second
+
+// Config is the jump host and how to log in to it.
+type Config struct {
+	Host       string // host or host:port (port 22 by default)
+	User       string // default: the login name
+	KeyFile    string // private key; "~/" is expanded
+	Agent      bool   // authenticate with ssh-agent (SSH_AUTH_SOCK)
+	KnownHosts string // default ~/.ssh/known_hosts
+
+	// Passphrase asks for the passphrase of an encrypted KeyFile; nil
+	// means encrypted keys cannot be used.
+	Passphrase func(prompt string) (string, error)
+}
+
+// Tunnel listens on a local port and forwards every connection to the
+// remote address through the jump host. If the SSH connection drops,
+// the next forwarded connection logs in again.
+type Tunnel struct {
+	cfg    Config
+	ln     net.Listener
+	remote string
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	client *ssh.Client
+	lost   chan struct{} // closed once client's connection has ended
+	closed bool
+}
+
+// Open logs in to the jump host, checks that remote ("host:port", as
+// the jump host resolves it) can be reached from there, and starts
+// forwarding a local port to it.
+func Open(cfg Config, remote string) (*Tunnel, error) {
+	cfg.Passphrase = rememberPassphrase(cfg.Passphrase)
+	client, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Fail now rather than on the driver's first connection, where the
+	// error would only say "EOF".
+	c, err := client.Dial("tcp", remote)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ssh %s: reaching %s: %w", cfg.Host, remote, err)
+	}
+	c.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	t := &Tunnel{cfg: cfg, ln: ln, remote: remote}
+	t.setClient(client)
+	t.wg.Add(1)
+	go t.serve()
+	return t, nil
+}
+
+// LocalAddr is the forwarded local "127.0.0.1:port".
+func (t *Tunnel) LocalAddr() string { return t.ln.Addr().String() }
+
+// Close stops listening and closes the SSH connection, and with it every
+// forwarded connection.
+func (t *Tunnel) Close() error {
+	t.ln.Close()
+	t.mu.Lock()
+	t.closed = true
+	err := t.client.Close()
+	t.mu.Unlock()
+	t.wg.Wait()
+	return err
+}
+
+// setClient makes c the SSH connection new forwards go through, and
+// watches for its end. t.mu must be held, or t not yet shared.
+func (t *Tunnel) setClient(c *ssh.Client) {
+	lost := make(chan struct{})
+	go func() {
+		c.Wait()
+		close(lost)
+	}()
+	t.client, t.lost = c, lost
+}
+
+// sshClient is the SSH connection to forward through, logging in again
+// if the last one has ended (the jump host restarted, the network
+// dropped, an idle timeout).
+func (t *Tunnel) sshClient() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, net.ErrClosed
+	}
+	select {
+	case <-t.lost:
+		c, err := dial(t.cfg)
+		if err != nil {
+			return nil, err
+		}
+		t.client.Close()
+		t.setClient(c)
+	default:
+	}
+	return t.client, nil
+}
+
+func (t *Tunnel) serve() {
+	defer t.wg.Done()
+	for {
+		local, err := t.ln.Accept()
+		if err != nil {
+			return // closed
+		}
+		t.wg.Add(1)
+		go t.forward(local)
+	}
+}
+
+func (t *Tunnel) forward(local net.Conn) {
+	defer t.wg.Done()
+	defer local.Close()
+	client, err := t.sshClient()
+	if err != nil {
+		return
+	}
+	remote, err := client.Dial("tcp", t.remote)
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(remote, local); done <- struct{}{} }()
+	go func() { io.Copy(local, remote); done <- struct{}{} }()
+	<-done
+}
+
+// rememberPassphrase asks once and gives the same answer when logging
+// in again: by then the TUI owns the terminal.
+func rememberPassphrase(ask func(string) (string, error)) func(string) (string, error) {
+	if ask == nil {
+		return nil
+	}
+	var (
+		mu     sync.Mutex
+		answer string
+		asked  bool
+	)
+	return func(prompt string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if asked {
+			return answer, nil
+		}
+		p, err := ask(prompt)
+		if err == nil {
+			answer, asked = p, true
+		}
+		return p, err
+	}
+}
+
+// dial connects and authenticates to the jump host.
+func dial(cfg Config) (*ssh.Client, error) {
+	addr := cfg.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	name := cfg.User
+	if name == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		name = u.Username
+	}
+
+	hostKey, algos, err := hostKeyCallback(cfg.KnownHosts, addr)
+	if err != nil {
+		return nil, err
+	}
+	auth, closeAgent, err := authMethods(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:              name,
+		Auth:              auth,
+		HostKeyCallback:   hostKey,
+		HostKeyAlgorithms: algos,
+		Timeout:           dialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s@%s: %w", name, addr, err)
+	}
+	return client, nil
+}
+
+// hostKeyCallback verifies the jump host against known_hosts. It also
+// returns the key types known for addr, so the server is asked for one
+// of those rather than a type that is not on file.
+func hostKeyCallback(path, addr string) (ssh.HostKeyCallback, []string, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, err
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	path = expandHome(path)
+	check, err := knownhosts.New(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ssh: reading known hosts: %w", err)
+	}
+
+	callback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			host, port, _ := net.SplitHostPort(hostname)
+			if port != "22" {
+				host = "-p " + port + " " + host
+			}
+			return fmt.Errorf("%s is not in %s; check its key and add it with: ssh-keyscan %s >> %s", hostname, path, host, path)
+		}
+		return err
+	}
+
+	var algos []string
+	var keyErr *knownhosts.KeyError
+	if err := check(addr, &net.TCPAddr{IP: net.IPv4zero}, probeKey{}); errors.As(err, &keyErr) {
+		for _, k := range keyErr.Want {
+			if t := k.Key.Type(); t == ssh.KeyAlgoRSA {
+				algos = append(algos, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, t)
+			} else {
+				algos = append(algos, t)
+			}
+		}
+	}
+	return callback, algos, nil
+}
+
+// probeKey matches no known host key; checking it lists the keys that
+// are on file for a host.
+type probeKey struct{}
+
+func (probeKey) Type() string                        { return "probe" }
+func (probeKey) Marshal() []byte                     { return []byte("probe") }
+func (probeKey) Verify([]byte, *ssh.Signature) error { return errors.New("probe key") }
+
+// authMethods are the key file, ssh-agent and, when neither is given,
+// the default keys in ~/.ssh, as ssh tries them. The returned func closes
+// the agent connection.
+func authMethods(cfg Config) ([]ssh.AuthMethod, func(), error) {
+	var (
+		methods []ssh.AuthMethod
+		signers []ssh.Signer
+	)
+	closeAgent := func() {}
+
+	if cfg.KeyFile != "" {
+		s, err := loadKey(expandHome(cfg.KeyFile), cfg.Passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		signers = append(signers, s)
+	}
+	useDefaults := cfg.KeyFile == "" && !cfg.Agent
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" && (cfg.Agent || useDefaults) {
+		conn, err := net.Dial("unix", sock)
+		if err != nil && cfg.Agent {
+			return nil, nil, fmt.Errorf("ssh-agent: %w", err)
+		}
+		if err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { conn.Close() }
+		}
+	} else if cfg.Agent {
+		return nil, nil, errors.New("ssh-agent: SSH_AUTH_SOCK is not set")
+	}
+	if useDefaults {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+				if s, err := loadKey(filepath.Join(home, ".ssh", name), nil); err == nil {
+					signers = append(signers, s)
+				}
+			}
+		}
+	}
+
+	if len(signers) > 0 {
+		methods = append([]ssh.AuthMethod{ssh.PublicKeys(signers...)}, methods...)
+	}
+	if len(methods) == 0 {
+		closeAgent()
+		return nil, nil, errors.New("ssh: no key to log in with (give a key file or run ssh-agent)")
+	}
+	return methods, closeAgent, nil
+}
+
+// loadKey reads a private key, asking for its passphrase if it is
+// encrypted and passphrase is not nil.
+func loadKey(path string, passphrase func(string) (string, error)) (ssh.Signer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ssh.ParsePrivateKey(b)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) && passphrase != nil {
+		p, perr := passphrase("Passphrase for " + path + ": ")
+		if perr != nil {
+			return nil, perr
+		}
+		s, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(p))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
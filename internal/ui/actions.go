@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"strings"
+)
+
+// action is something a key or the command palette can trigger. The id
+// is "<scope>.<name>"; the scope says where its keys are active.
+type action struct {
+	id   string
+	keys []string // default bindings
+	help string
+	run  func(s *uiState) // nil: handled by the overlay that owns the scope
+}
+
+// keyScopes are the help overlay's sections, in order.
+var keyScopes = []struct{ id, title string }{
+	{"global", "Global"},
+	{"overlay", "Overlays"},
+	{"tables", "Tables pane"},
+	{"results", "Results pane"},
+	{"row", "Row detail"},
+	{"query", "Query input"},
+	{"queries", "Saved queries"},
+	{"json", "JSON viewer"},
+	{"array", "Array viewer"},
+	{"blob", "Blob viewer"},
+	{"plan", "Query plan"},
+}
+
+// actions is the registry of everything bindable, in help order. Keys
+// and the command palette both go through runAction. It is filled in
+// init since the run funcs lead back to it (help, palette).
+var actions []action
+
+func init() {
+	actions = []action{
+		{"global.quit", []string{"Ctrl+Q", "Ctrl+C"}, "Quit", func(s *uiState) { s.app.Stop() }},
+		{"global.help", []string{"Ctrl+/"}, "Toggle this help", (*uiState).toggleHelp},
+		{"global.palette", []string{"Ctrl+P"}, "Command palette", (*uiState).togglePalette},
+		{"global.queries", []string{"Ctrl+O"}, "Saved queries: insert or run one", (*uiState).showQueries},
+		{"global.refresh-schema", []string{"Ctrl+R"}, "Refresh schema cache (tables, columns)", (*uiState).refreshSchema},
+		{"global.reconnect", []string{"F5"}, "Reconnect: drop idle connections and check the server now", (*uiState).reconnect},
+		{"global.open-connection", []string{"Ctrl+T"}, "Open another connection", (*uiState).showConnections},
+		{"global.read-only", []string{"F4"}, "Toggle read-only: refuse anything but a single SELECT", (*uiState).toggleReadOnly},
+		{"global.info", []string{"F2"}, "Server and session info", (*uiState).showInfo},
+		{"global.notify", []string{"Ctrl+N"}, "LISTEN/NOTIFY monitor (postgres)", (*uiState).toggleNotify},
+		{"global.explain", []string{"Ctrl+E"}, "EXPLAIN the query (a in the plan: ANALYZE, rolled back)",
+			func(s *uiState) { s.explainCurrentQuery(false) }},
+		{"global.focus-tables", []string{"Ctrl+H"}, "Focus tables (left)", func(s *uiState) { s.app.SetFocus(s.tables) }},
+		{"global.focus-results", []string{"Ctrl+L"}, "Focus results (right)", func(s *uiState) { s.app.SetFocus(s.result) }},
+		{"global.focus-query", []string{"Ctrl+J", "Ctrl+:"}, "Focus query (down)", func(s *uiState) { s.app.SetFocus(s.query) }},
+		{"global.focus-status", []string{"Ctrl+K"}, "Focus status (up)", func(s *uiState) { s.app.SetFocus(s.status) }},
+
+		{"overlay.close", []string{"Esc", "Enter", "Ctrl+Q", "Ctrl+/"}, "Close help, row detail or structure", nil},
+		{"overlay.close-viewer", []string{"Esc", "Ctrl+Q"}, "Close a viewer, the column chooser, palette or saved queries", nil},
+
+		{"tables.open", []string{"Enter"}, "SELECT * FROM <table> LIMIT 100 (on a schema: fold/unfold)",
+			func(s *uiState) { s.openTableEntry(s.tables.GetCurrentItem()) }},
+		{"tables.structure", []string{"d"}, "Show table structure (columns and types)", (*uiState).showStructure},
+		{"tables.filter", []string{"/"}, "Fuzzy filter by schema and table name (Esc clears)", (*uiState).openTableFilter},
+		{"tables.pin", []string{"p"}, "Pin/unpin table (pinned tables are listed first)", (*uiState).togglePinSelected},
+
+		{"results.expand", []string{"Enter"}, "Expand current row (JSON/binary/array cells open a viewer)", (*uiState).expandCurrentRow},
+		{"results.transpose", []string{"t"}, "Transpose: columns as rows, records as columns", (*uiState).toggleTranspose},
+		{"results.sort", []string{"s"}, "Sort by column: ascending, descending, off (or click header)", func(s *uiState) {
+			_, col := s.selectedCell()
+			s.cycleSort(s.dataCol(col))
+		}},
+		{"results.filter", []string{"f"}, "Filter rows: status = 'failed' AND amount > 100 (Esc clears)",
+			func(s *uiState) { s.openGridPrompt("filter", s.grid.filter) }},
+		{"results.search", []string{"/"}, "Search cell text (Esc clears)",
+			func(s *uiState) { s.openGridPrompt("search", s.grid.search) }},
+		{"results.next-match", []string{"n"}, "Next search match", func(s *uiState) { s.nextMatch(1) }},
+		{"results.prev-match", []string{"N"}, "Previous search match", func(s *uiState) { s.nextMatch(-1) }},
+		{"results.export", []string{"E"}, "Export visible rows and columns to a file (.csv, .tsv, .json, .txt)",
+			func(s *uiState) { s.openGridPrompt("export", s.exportFileName()) }},
+		{"results.hide-column", []string{"x"}, "Hide column", columnAction("results.hide-column")},
+		{"results.columns", []string{"c"}, "Choose visible columns", columnAction("results.columns")},
+		{"results.move-left", []string{"<"}, "Move column left", columnAction("results.move-left")},
+		{"results.move-right", []string{">"}, "Move column right", columnAction("results.move-right")},
+		{"results.freeze", []string{"F"}, "Freeze columns up to here (again: unfreeze)", columnAction("results.freeze")},
+		{"results.widen", []string{"+", "="}, "Widen column", columnAction("results.widen")},
+		{"results.narrow", []string{"-"}, "Narrow column", columnAction("results.narrow")},
+		{"results.full-width", []string{"w"}, "Toggle full content width", columnAction("results.full-width")},
+		{"results.reset-layout", []string{"R"}, "Reset column layout (remembered per table)", columnAction("results.reset-layout")},
+
+		{"row.next", []string{"n"}, "Next row", nil},
+		{"row.prev", []string{"p"}, "Previous row", nil},
+		{"row.find-column", []string{"/"}, "Find column by name", nil},
+
+		{"query.run", []string{"Enter"}, "Run SQL in the input", func(s *uiState) {
+			if sql := strings.TrimSpace(s.query.GetText()); sql != "" {
+				s.runQuery(sql) // synchronous
+			}
+		}},
+		{"query.complete", []string{"Tab"}, "Complete keyword / table / column at the cursor (↑↓ pick, Enter/Tab accept)", func(s *uiState) {
+			if s.app.GetFocus() != s.query {
+				s.app.SetFocus(s.query)
+			}
+			s.complete()
+		}},
+
+		{"queries.insert", []string{"Enter"}, "Put the query (parameters filled in) into the query input", nil},
+		{"queries.run", []string{"Ctrl+R"}, "Run the query", nil},
+
+		{"json.expand-all", []string{"e"}, "Expand all", nil},
+		{"json.collapse-all", []string{"c"}, "Collapse all", nil},
+		{"json.filter", []string{"/"}, "Filter by path (.a.b[0])", nil},
+		{"json.copy", []string{"y"}, "Copy SQL accessor for the node", nil},
+
+		{"array.copy", []string{"y"}, "Copy SQL accessor for the element", nil},
+
+		{"blob.preview", []string{"p"}, "Toggle decoded preview (gzip, UTF-16, text)", nil},
+		{"blob.save", []string{"s"}, "Save blob to file", nil},
+
+		{"plan.analyze", []string{"a"}, "Re-run with ANALYZE (executes, then rolls back)", nil},
+	}
+}
+
+// columnAction runs one of the column layout actions (see columnKey).
+func columnAction(id string) func(s *uiState) {
+	return func(s *uiState) { s.columnKey(id) }
+}
+
+func findAction(id string) *action {
+	for i := range actions {
+		if actions[i].id == id {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// runAction triggers the action id, from a key or the palette.
+func (s *uiState) runAction(id string) {
+	a := findAction(id)
+	if a == nil || a.run == nil {
+		return
+	}
+	if strings.HasPrefix(id, "results.") && s.lastRows == nil {
+		s.setStatus(dimTag + "No results yet.[-]")
+		return
+	}
+	a.run(s)
+}
+
+// actionTitle is how the palette lists a: "Results pane: Hide column".
+func actionTitle(a *action) string {
+	scope, _, _ := strings.Cut(a.id, ".")
+	for _, sc := range keyScopes {
+		if sc.id == scope {
+			return sc.title + ": " + a.help
+		}
+	}
+	return a.help
+}
@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// pgArray is a parsed postgres array literal. Elements are either
+// scalars (possibly NULL) or nested arrays for multi-dimensional values.
+type pgArray struct {
+	lower int // subscript of the first element: 1 unless the bounds say otherwise
+	elems []pgArrayElem
+}
+
+type pgArrayElem struct {
+	value  string
+	isNull bool
+	nested *pgArray
+}
+
+// isPGArrayColumn reports whether v is a postgres array literal. The
+// postgres adapter reports array types as "<elem>[]".
+func isPGArrayColumn(col db.Column, v any) bool {
+	s, ok := v.(string)
+	if !ok || !strings.HasSuffix(col.Type, "[]") {
+		return false
+	}
+	_, err := parsePGArray(s)
+	return err == nil
+}
+
+// parsePGArray parses postgres' text array output, e.g.
+// {1,2,NULL}, {"a b","c\"d"}, {{1,2},{3,4}} or [0:1]={1,2}.
+func parsePGArray(s string) (*pgArray, error) {
+	s = strings.TrimSpace(s)
+	var lowers []int
+	if strings.HasPrefix(s, "[") {
+		// explicit bounds decoration, one [lo:hi] per dimension
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("bad array bounds")
+		}
+		var err error
+		if lowers, err = parsePGArrayBounds(s[:eq]); err != nil {
+			return nil, err
+		}
+		s = s[eq+1:]
+	}
+	arr, rest, err := parsePGArrayAt(s)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("trailing data after array")
+	}
+	setPGArrayLower(arr, lowers)
+	return arr, nil
+}
+
+// parsePGArrayBounds returns the lower bound of each dimension of
+// "[0:1][-2:3]".
+func parsePGArrayBounds(s string) ([]int, error) {
+	var lowers []int
+	for s != "" {
+		end := strings.Index(s, "]")
+		if !strings.HasPrefix(s, "[") || end < 0 {
+			return nil, fmt.Errorf("bad array bounds")
+		}
+		lo, _, ok := strings.Cut(s[1:end], ":")
+		n, err := strconv.Atoi(lo)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("bad array bounds")
+		}
+		lowers = append(lowers, n)
+		s = s[end+1:]
+	}
+	return lowers, nil
+}
+
+// setPGArrayLower applies the lower bounds of the dimensions to arr and
+// its nested arrays; dimensions without one start at 1.
+func setPGArrayLower(arr *pgArray, lowers []int) {
+	arr.lower = 1
+	if len(lowers) > 0 {
+		arr.lower = lowers[0]
+		lowers = lowers[1:]
+	}
+	for _, el := range arr.elems {
+		if el.nested != nil {
+			setPGArrayLower(el.nested, lowers)
+		}
+	}
+}
+
+func parsePGArrayAt(s string) (*pgArray, string, error) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, s, fmt.Errorf("array must start with {")
+	}
+	s = s[1:]
+	arr := &pgArray{}
+	if strings.HasPrefix(s, "}") {
+		return arr, s[1:], nil
+	}
+
+	for {
+		var el pgArrayElem
+		switch {
+		case strings.HasPrefix(s, "{"):
+			nested, rest, err := parsePGArrayAt(s)
+			if err != nil {
+				return nil, s, err
+			}
+			el.nested = nested
+			s = rest
+		case strings.HasPrefix(s, `"`):
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, s, fmt.Errorf("unterminated quoted element")
+			}
+			el.value = b.String()
+			s = s[i+1:]
+		default:
+			end := strings.IndexAny(s, ",}")
+			if end < 0 {
+				return nil, s, fmt.Errorf("unterminated array")
+			}
+			tok := strings.TrimSpace(s[:end])
+			if strings.EqualFold(tok, "NULL") {
+				el.isNull = true
+			} else {
+				el.value = tok
+			}
+			s = s[end:]
+		}
+		arr.elems = append(arr.elems, el)
+
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case strings.HasPrefix(s, "}"):
+			return arr, s[1:], nil
+		default:
+			return nil, s, fmt.Errorf("expected , or } in array")
+		}
+	}
+}
+
+// formatPGArrayLines lists elements one per line with their subscripts,
+// as used by the row detail overlay.
+func formatPGArrayLines(arr *pgArray, prefix string, out *[]string) {
+	for i, el := range arr.elems {
+		sub := fmt.Sprintf("%s[%d]", prefix, arr.lower+i)
+		switch {
+		case el.nested != nil:
+			formatPGArrayLines(el.nested, sub, out)
+		case el.isNull:
+			*out = append(*out, sub+" NULL")
+		default:
+			*out = append(*out, sub+" "+el.value)
+		}
+	}
+}
+
+func buildArrayTreeNode(arr *pgArray, label string, subs []int) *tview.TreeNode {
+	node := tview.NewTreeNode(fmt.Sprintf("%s "+dimTag+"%d items[-]", label, len(arr.elems))).
+		SetReference(subs).
+		SetSelectable(true)
+	for i, el := range arr.elems {
+		childSubs := append(append([]int{}, subs...), arr.lower+i)
+		childLabel := fmt.Sprintf(dimTag+"[%d][-]", arr.lower+i)
+		switch {
+		case el.nested != nil:
+			node.AddChild(buildArrayTreeNode(el.nested, childLabel, childSubs))
+		case el.isNull:
+			node.AddChild(tview.NewTreeNode(childLabel + " [" + jsonLiteralColor + "]NULL[-]").
+				SetReference(childSubs))
+		default:
+			node.AddChild(tview.NewTreeNode(childLabel + " " + tview.Escape(el.value)).
+				SetReference(childSubs))
+		}
+	}
+	return node
+}
+
+// showArrayViewer opens a collapsible view of a postgres array cell.
+func (s *uiState) showArrayViewer(col db.Column, raw string) {
+	arr, err := parsePGArray(raw)
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Invalid array:[-] %v", err))
+		return
+	}
+
+	root := buildArrayTreeNode(arr, "["+jsonKeyColor+"]"+tview.Escape(col.Name)+"[-]", nil)
+	tree := tview.NewTreeView().
+		SetRoot(root).
+		SetCurrentNode(root)
+	themeSelection(tree)
+
+	accessor := func(node *tview.TreeNode) string {
+		subs, _ := node.GetReference().([]int)
+		var b strings.Builder
+		b.WriteString(sqllex.QuoteIdent(col.Name, sqllex.Postgres))
+		for _, i := range subs {
+			b.WriteString("[" + strconv.Itoa(i) + "]")
+		}
+		return b.String()
+	}
+
+	info := tview.NewTextView().SetDynamicColors(true)
+	tree.SetChangedFunc(func(node *tview.TreeNode) {
+		info.SetText(dimTag + tview.Escape(accessor(node)) + "[-]")
+	})
+	info.SetText(dimTag + tview.Escape(accessor(root)) + "[-]")
+
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+	tree.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if s.keys.is(ev, "array.copy") {
+			if node := tree.GetCurrentNode(); node != nil {
+				expr := accessor(node)
+				s.copyToClipboard(expr)
+				s.setStatus(fmt.Sprintf(greenTag+"Copied:[-] %s", tview.Escape(expr)))
+			}
+			return nil
+		}
+		return ev
+	})
+
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(dimTag + "Enter[-] fold  " + s.keys.hint("array.copy", "copy subscript") + "  " +
+			s.keys.hint("overlay.close-viewer", "close"))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(info, 1, 0, false).
+		AddItem(help, 1, 0, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Array: %s (%s) ", tview.Escape(col.Name), col.Type)).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("arrayView", centered(frame), true)
+	s.app.SetFocus(tree)
+}
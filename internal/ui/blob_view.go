@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/blob"
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// maxBlobDump caps how much of a blob is rendered in the hex view;
+// saving to a file always writes the full value.
+const maxBlobDump = 64 * 1024
+
+// showBlobViewer opens a hex+ASCII dump of one binary cell with an
+// optional decoded preview and a save-to-file prompt.
+func (s *uiState) showBlobViewer(col db.Column, data []byte) {
+	kind := blob.Sniff(data)
+	previewTitle, previewBody, hasPreview := blob.Preview(data, maxBlobDump)
+
+	info := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[::b]%s[::-]  "+dimTag+"%d bytes, %s[-]",
+			tview.Escape(col.Name), len(data), kind))
+
+	body := tview.NewTextView().
+		SetScrollable(true).
+		SetWrap(false)
+
+	showingPreview := false
+	showDump := func() {
+		body.SetWrap(false)
+		body.SetText(blob.HexDump(data, maxBlobDump))
+		body.ScrollToBeginning()
+	}
+	showDump()
+
+	save := tview.NewInputField().
+		SetLabel("save to ").
+		SetFieldWidth(0)
+
+	keys := s.keys.hint("blob.save", "save to file") + "  " + s.keys.hint("overlay.close-viewer", "close")
+	if hasPreview {
+		keys = s.keys.hint("blob.preview", "preview") + "  " + keys
+	}
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(keys)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(info, 1, 0, false).
+		AddItem(body, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	save.SetDoneFunc(func(key tcell.Key) {
+		defer func() {
+			layout.RemoveItem(save)
+			s.app.SetFocus(body)
+		}()
+		if key != tcell.KeyEnter {
+			return
+		}
+		path := expandHome(strings.TrimSpace(save.GetText()))
+		if path == "" {
+			return
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			s.setStatus(fmt.Sprintf(redTag+"Save failed:[-] %v", err))
+			return
+		}
+		s.setStatus(fmt.Sprintf(greenTag+"Saved %d bytes to[-] %s", len(data), tview.Escape(path)))
+	})
+
+	body.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch s.keys.action(ev, "blob") {
+		case "blob.preview":
+			if !hasPreview {
+				return nil
+			}
+			showingPreview = !showingPreview
+			if showingPreview {
+				body.SetWrap(true)
+				body.SetText(previewBody)
+				body.ScrollToBeginning()
+				info.SetText(fmt.Sprintf("[::b]%s[::-]  "+dimTag+"%s[-]", tview.Escape(col.Name), previewTitle))
+			} else {
+				showDump()
+				info.SetText(fmt.Sprintf("[::b]%s[::-]  "+dimTag+"%d bytes, %s[-]",
+					tview.Escape(col.Name), len(data), kind))
+			}
+			return nil
+		case "blob.save":
+			save.SetText(defaultBlobFileName(col.Name, kind))
+			layout.AddItem(save, 1, 0, true)
+			s.app.SetFocus(save)
+			return nil
+		}
+		return ev
+	})
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(" Blob ").
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("blobView", centered(frame), true)
+	s.app.SetFocus(body)
+}
+
+// defaultBlobFileName suggests a file name with an extension matching kind.
+func defaultBlobFileName(col string, kind blob.Kind) string {
+	ext := ".bin"
+	switch kind {
+	case blob.KindPNG:
+		ext = ".png"
+	case blob.KindJPEG:
+		ext = ".jpg"
+	case blob.KindGIF:
+		ext = ".gif"
+	case blob.KindPDF:
+		ext = ".pdf"
+	case blob.KindGzip:
+		ext = ".gz"
+	case blob.KindZip:
+		ext = ".zip"
+	case blob.KindText, blob.KindUTF16LE, blob.KindUTF16BE:
+		ext = ".txt"
+	}
+	return col + ext
+}
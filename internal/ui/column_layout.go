@@ -0,0 +1,337 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+const (
+	maxColWidth  = 40 // default cap for auto-sized columns
+	colWidthStep = 4
+	fullColWidth = -1 // Widths value: size to content, no cap
+)
+
+// columnLayout is how a table's columns are shown in the results grid.
+// Columns are kept by name so the layout survives added or dropped
+// columns; it is remembered per table in prefs.
+type columnLayout struct {
+	Order  []string       `json:"order,omitempty"` // display order; unknown columns go last
+	Hidden []string       `json:"hidden,omitempty"`
+	Frozen int            `json:"frozen,omitempty"` // leading columns kept while scrolling
+	Widths map[string]int `json:"widths,omitempty"` // fixed width, or fullColWidth
+}
+
+func (l *columnLayout) isHidden(name string) bool {
+	for _, h := range l.Hidden {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *columnLayout) setHidden(name string, hidden bool) {
+	var out []string
+	for _, h := range l.Hidden {
+		if h != name {
+			out = append(out, h)
+		}
+	}
+	if hidden {
+		out = append(out, name)
+	}
+	l.Hidden = out
+}
+
+// resultTable guesses which table a query reads, for remembering its
+// column layout. Only single-table queries qualify.
+func (s *uiState) resultTable(sql string) string {
+	refs := fromRefs(sqllex.Lex(sql, s.dialect))
+	if len(refs) != 1 {
+		return ""
+	}
+	if t, ok := s.schema.resolveTable(refs[0].name); ok {
+		return t
+	}
+	return unquoteIdent(refs[0].name)
+}
+
+// loadLayout picks the layout for the current result: the remembered one
+// for its table, or a throwaway one.
+func (s *uiState) loadLayout() {
+	g := s.grid
+	if g.table != "" {
+		if l, ok := s.prefs.Layouts[g.table]; ok {
+			g.layout = l
+			return
+		}
+	}
+	g.layout = &columnLayout{}
+}
+
+// saveLayout persists the current layout when the result has a table.
+func (s *uiState) saveLayout() {
+	g := s.grid
+	if g.table == "" {
+		return
+	}
+	if s.prefs.Layouts == nil {
+		s.prefs.Layouts = map[string]*columnLayout{}
+	}
+	s.prefs.Layouts[g.table] = g.layout
+	if err := s.prefs.save(s.prefsPath); err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Could not save column layout:[-] %v", err))
+	}
+}
+
+// orderedColumns returns all data column indices in layout order,
+// hidden ones included.
+func (s *uiState) orderedColumns() []int {
+	cols := s.lastRows.Columns
+	used := make([]bool, len(cols))
+	var out []int
+	for _, name := range s.grid.layout.Order {
+		for i, c := range cols {
+			if !used[i] && c.Name == name {
+				used[i] = true
+				out = append(out, i)
+				break
+			}
+		}
+	}
+	for i := range cols {
+		if !used[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// layoutColumns computes the visible columns (data indices, display order).
+func (s *uiState) layoutColumns() {
+	g := s.grid
+	g.cols = g.cols[:0]
+	for _, i := range s.orderedColumns() {
+		if !g.layout.isHidden(s.lastRows.Columns[i].Name) {
+			g.cols = append(g.cols, i)
+		}
+	}
+}
+
+// dataCol maps a display column to the data column (-1 if none).
+func (s *uiState) dataCol(displayCol int) int {
+	if displayCol < 0 || displayCol >= len(s.grid.cols) {
+		return -1
+	}
+	return s.grid.cols[displayCol]
+}
+
+// displayCol maps a data column to its display column (-1 if hidden).
+func (s *uiState) displayCol(dataCol int) int {
+	for i, c := range s.grid.cols {
+		if c == dataCol {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnWidthLimit is the most cells a column may take.
+func (g *gridState) columnWidthLimit(name string) (limit int, fixed bool) {
+	switch w := g.layout.Widths[name]; {
+	case w == fullColWidth:
+		return 1 << 30, false
+	case w > 0:
+		return w, true
+	}
+	return maxColWidth, false
+}
+
+// columnKey handles the column management keys of the results grid.
+func (s *uiState) columnKey(id string) bool {
+	g := s.grid
+	row, dc := s.selectedCell()
+	col := s.dataCol(dc)
+	if col < 0 {
+		return false
+	}
+	name := s.lastRows.Columns[col].Name
+
+	switch id {
+	case "results.hide-column":
+		if len(g.cols) == 1 {
+			s.setStatus(yellowTag + "Cannot hide the last visible column.[-]")
+			return true
+		}
+		g.layout.setHidden(name, true)
+		s.setStatus(fmt.Sprintf(greenTag+"Hid[-] %s "+dimTag+"(%s to show columns)[-]",
+			tview.Escape(name), tview.Escape(s.keys.label("results.columns"))))
+		if dc >= len(g.cols)-1 {
+			dc--
+		}
+	case "results.move-left", "results.move-right":
+		dc = s.moveColumn(col, id == "results.move-right")
+	case "results.freeze": // up to here, or unfreeze
+		if g.layout.Frozen == dc+1 {
+			g.layout.Frozen = 0
+			s.setStatus(dimTag + "Columns unfrozen.[-]")
+		} else {
+			g.layout.Frozen = dc + 1
+			s.setStatus(fmt.Sprintf(greenTag+"Froze %d column(s)[-]", dc+1))
+		}
+	case "results.widen", "results.narrow":
+		w := s.columnWidth(dc)
+		if id == "results.narrow" {
+			w -= colWidthStep
+		} else {
+			w += colWidthStep
+		}
+		if w < 3 {
+			w = 3
+		}
+		if g.layout.Widths == nil {
+			g.layout.Widths = map[string]int{}
+		}
+		g.layout.Widths[name] = w
+	case "results.full-width": // toggle
+		if g.layout.Widths == nil {
+			g.layout.Widths = map[string]int{}
+		}
+		if g.layout.Widths[name] == fullColWidth {
+			delete(g.layout.Widths, name)
+		} else {
+			g.layout.Widths[name] = fullColWidth
+		}
+	case "results.reset-layout":
+		g.layout.Order, g.layout.Hidden, g.layout.Frozen, g.layout.Widths = nil, nil, 0, nil
+		s.setStatus(dimTag + "Column layout reset.[-]")
+	case "results.columns":
+		s.showColumnChooser()
+		return true
+	default:
+		return false
+	}
+
+	s.saveLayout()
+	s.applyView()
+	if dc < 0 {
+		dc = 0
+	}
+	s.selectCell(row, dc)
+	return true
+}
+
+// columnWidth is the drawn width of a display column.
+func (s *uiState) columnWidth(displayCol int) int {
+	if s.grid.transposed {
+		if limit, _ := s.grid.columnWidthLimit(s.lastRows.Columns[s.dataCol(displayCol)].Name); limit < 1<<30 {
+			return limit
+		}
+		return maxColWidth
+	}
+	if cell := s.result.GetCell(0, displayCol); cell != nil {
+		return runeLen(cell.Text)
+	}
+	return maxColWidth
+}
+
+// moveColumn swaps col with its visible neighbour and returns its new
+// display column.
+func (s *uiState) moveColumn(col int, right bool) int {
+	g := s.grid
+	dc := s.displayCol(col)
+	other := dc - 1
+	if right {
+		other = dc + 1
+	}
+	if other < 0 || other >= len(g.cols) {
+		return dc
+	}
+
+	all := s.orderedColumns()
+	var a, b int
+	for i, c := range all {
+		switch c {
+		case col:
+			a = i
+		case g.cols[other]:
+			b = i
+		}
+	}
+	all[a], all[b] = all[b], all[a]
+
+	g.layout.Order = g.layout.Order[:0]
+	for _, c := range all {
+		g.layout.Order = append(g.layout.Order, s.lastRows.Columns[c].Name)
+	}
+	return other
+}
+
+// showColumnChooser lists all columns; Enter or Space toggles visibility.
+func (s *uiState) showColumnChooser() {
+	g := s.grid
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	themeSelection(list)
+
+	all := s.orderedColumns()
+	label := func(i int) string {
+		name := s.lastRows.Columns[i].Name
+		mark := greenTag + "✓[-]"
+		if g.layout.isHidden(name) {
+			mark = " "
+		}
+		return fmt.Sprintf("%s %s "+dimTag+"%s[-]", mark, tview.Escape(name), tview.Escape(s.lastRows.Columns[i].Type))
+	}
+	for _, i := range all {
+		list.AddItem(label(i), "", 0, nil)
+	}
+
+	toggle := func() {
+		idx := list.GetCurrentItem()
+		name := s.lastRows.Columns[all[idx]].Name
+		hidden := !g.layout.isHidden(name)
+		if hidden && len(g.cols) == 1 {
+			s.setStatus(yellowTag + "Cannot hide the last visible column.[-]")
+			return
+		}
+		g.layout.setHidden(name, hidden)
+		list.SetItemText(idx, label(all[idx]), "")
+		s.saveLayout()
+		s.applyView()
+	}
+	list.SetSelectedFunc(func(int, string, string, rune) { toggle() })
+	list.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if ev.Key() == tcell.KeyRune && ev.Rune() == ' ' {
+			toggle()
+			return nil
+		}
+		return ev
+	})
+
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(dimTag + "Enter/Space[-] show/hide  " + s.keys.hint("overlay.close-viewer", "close"))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	title := " Columns "
+	if g.table != "" {
+		title = fmt.Sprintf(" Columns: %s ", g.table)
+	}
+	frame.SetBorder(true).
+		SetTitle(title).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("columns", centered(frame), true)
+	s.app.SetFocus(list)
+}
@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// maxCompletions caps the popup; more than this is noise anyway.
+const maxCompletions = 50
+
+// completionState is the open completion drop-down, if any.
+type completionState struct {
+	list   *tview.List
+	prefix string   // the part of the word typed so far
+	items  []string // the candidates, as listed
+}
+
+// tableRef is a table mentioned in a FROM/JOIN/UPDATE/INTO clause.
+type tableRef struct {
+	name  string
+	alias string
+}
+
+// fromRefs extracts table references and their aliases from toks.
+func fromRefs(toks []sqllex.Token) []tableRef {
+	var sig []sqllex.Token
+	for _, t := range toks {
+		if t.Kind != sqllex.Whitespace && t.Kind != sqllex.Comment {
+			sig = append(sig, t)
+		}
+	}
+
+	var refs []tableRef
+	for i := 0; i < len(sig); i++ {
+		if sig[i].Kind != sqllex.Keyword {
+			continue
+		}
+		kw := strings.ToUpper(sig[i].Text)
+		if kw != "FROM" && kw != "JOIN" && kw != "UPDATE" && kw != "INTO" {
+			continue
+		}
+		for {
+			// dotted name: ident (. ident)*
+			j := i + 1
+			isName := func(k int) bool {
+				return k < len(sig) && (sig[k].Kind == sqllex.Ident || sig[k].Kind == sqllex.QuotedIdent)
+			}
+			if !isName(j) {
+				break
+			}
+			var name strings.Builder
+			name.WriteString(sig[j].Text)
+			j++
+			for j+1 < len(sig) && sig[j].Kind == sqllex.Punct && sig[j].Text == "." && isName(j+1) {
+				name.WriteString("." + sig[j+1].Text)
+				j += 2
+			}
+			ref := tableRef{name: name.String()}
+			if j < len(sig) && sig[j].Kind == sqllex.Keyword && strings.EqualFold(sig[j].Text, "AS") {
+				j++
+			}
+			if j < len(sig) && sig[j].Kind == sqllex.Ident {
+				ref.alias = sig[j].Text
+				j++
+			}
+			refs = append(refs, ref)
+			i = j - 1
+			// FROM a, b, c
+			if kw == "FROM" && j < len(sig) && sig[j].Kind == sqllex.Punct && sig[j].Text == "," {
+				i = j
+				continue
+			}
+			break
+		}
+	}
+	return refs
+}
+
+// completeWord returns the identifier (possibly dotted) being typed at
+// the end of text.
+func completeWord(text string) string {
+	i := len(text)
+	for i > 0 {
+		r := rune(text[i-1])
+		if r == '_' || r == '.' || r == '$' || r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			i--
+			continue
+		}
+		break
+	}
+	return text[i:]
+}
+
+// completions returns the word being completed at byte offset cursor of
+// text and its candidates. Table references come from the whole text,
+// so columns complete before the FROM clause is reached.
+func (s *uiState) completions(full string, cursor int) (string, []string) {
+	text := full[:cursor]
+	toks := sqllex.Lex(text, s.dialect)
+	if n := len(toks); n > 0 {
+		switch toks[n-1].Kind {
+		case sqllex.String, sqllex.Comment, sqllex.QuotedIdent:
+			return "", nil // never complete inside literals
+		}
+	}
+
+	word := completeWord(text)
+	qualifier, prefix := "", word
+	if dot := strings.LastIndex(word, "."); dot >= 0 {
+		qualifier, prefix = word[:dot], word[dot+1:]
+	}
+
+	refs := fromRefs(sqllex.Lex(full, s.dialect))
+	var cands []string
+	seen := map[string]bool{}
+	add := func(c string) {
+		if c == "" || seen[strings.ToLower(c)] {
+			return
+		}
+		if !strings.HasPrefix(strings.ToLower(c), strings.ToLower(prefix)) {
+			return
+		}
+		seen[strings.ToLower(c)] = true
+		cands = append(cands, c)
+	}
+
+	if qualifier != "" {
+		// alias.col, table.col
+		target := ""
+		for _, r := range refs {
+			if r.alias != "" && strings.EqualFold(r.alias, qualifier) {
+				target = r.name
+				break
+			}
+		}
+		if target == "" {
+			target = qualifier
+		}
+		if table, ok := s.schema.resolveTable(target); ok {
+			for _, c := range s.schema.tableColumns(s.ctx, table) {
+				add(c.Name)
+			}
+		}
+		// schema.table
+		for _, t := range s.schema.tables() {
+			if strings.HasPrefix(strings.ToLower(t), strings.ToLower(qualifier)+".") {
+				add(t[len(qualifier)+1:])
+			}
+		}
+		return prefix, cands
+	}
+
+	if tableContext(toks, word) {
+		for _, sch := range s.schema.schemas() {
+			add(sch)
+		}
+		for _, t := range s.schema.tables() {
+			add(t)
+		}
+		return prefix, cands
+	}
+
+	if prefix == "" {
+		return prefix, nil
+	}
+
+	for _, r := range refs {
+		if r.alias != "" {
+			add(r.alias)
+		}
+		if table, ok := s.schema.resolveTable(r.name); ok {
+			for _, c := range s.schema.tableColumns(s.ctx, table) {
+				add(c.Name)
+			}
+		}
+	}
+	lower := prefix == strings.ToLower(prefix)
+	for _, kw := range sqllex.Keywords(s.dialect) {
+		if lower {
+			kw = strings.ToLower(kw)
+		}
+		add(kw)
+	}
+	for _, fn := range sqllex.Functions(s.dialect) {
+		if lower {
+			fn = strings.ToLower(fn)
+		}
+		add(fn)
+	}
+	for _, t := range s.schema.tables() {
+		add(t)
+	}
+	for _, sch := range s.schema.schemas() {
+		add(sch)
+	}
+	return prefix, cands
+}
+
+// tableContext reports whether the word being typed follows a keyword
+// that expects a table name.
+func tableContext(toks []sqllex.Token, word string) bool {
+	end := len(toks)
+	if word != "" && end > 0 {
+		end-- // the partial word itself
+	}
+	for i := end - 1; i >= 0; i-- {
+		t := toks[i]
+		switch t.Kind {
+		case sqllex.Whitespace, sqllex.Comment:
+			continue
+		case sqllex.Keyword:
+			switch strings.ToUpper(t.Text) {
+			case "FROM", "JOIN", "INTO", "UPDATE", "TABLE", "DESCRIBE":
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// complete handles Tab in the query input: the word at the cursor is
+// completed directly when there is one candidate, else a drop-down
+// opens with the candidates.
+func (s *uiState) complete() {
+	text := s.query.GetText()
+	cursor := s.queryCursor()
+	prefix, cands := s.completions(text, cursor)
+	switch len(cands) {
+	case 0:
+		s.closeCompletion()
+		s.setStatus(dimTag + "No completions.[-]")
+	case 1:
+		s.closeCompletion()
+		s.insertCompletion(cursor, prefix, cands[0])
+	default:
+		s.openCompletion(prefix, cands)
+	}
+}
+
+// queryCursor is the byte offset of the cursor in the query text (the
+// end of the selection, if there is one).
+func (s *uiState) queryCursor() int {
+	_, _, end := s.query.GetSelection()
+	return end
+}
+
+// openCompletion shows cands in the drop-down under (or over) the word
+// being completed, or updates it if it is open.
+func (s *uiState) openCompletion(prefix string, cands []string) {
+	sort.SliceStable(cands, func(i, j int) bool {
+		return len(cands[i]) < len(cands[j])
+	})
+	if len(cands) > maxCompletions {
+		cands = cands[:maxCompletions]
+	}
+
+	if s.completion == nil {
+		list := tview.NewList().
+			ShowSecondaryText(false).
+			SetHighlightFullLine(true).
+			SetMainTextStyle(tcell.StyleDefault.Background(tview.Styles.ContrastBackgroundColor).Foreground(tview.Styles.PrimaryTextColor)).
+			SetSelectedStyle(tcell.StyleDefault.Background(tview.Styles.PrimaryTextColor).Foreground(tview.Styles.ContrastBackgroundColor))
+		list.SetBackgroundColor(tview.Styles.ContrastBackgroundColor)
+		list.SetSelectedFunc(func(i int, _, _ string, _ rune) { // a click
+			s.acceptCompletion(i)
+			s.app.SetFocus(s.query)
+		})
+		s.completion = &completionState{list: list}
+		s.pages.AddPage("completion", list, false, true)
+		s.app.SetFocus(s.query) // AddPage focuses the list
+	}
+	c := s.completion
+	c.prefix, c.items = prefix, cands
+
+	c.list.Clear()
+	width := 0
+	for _, item := range cands {
+		c.list.AddItem(tview.Escape(item), "", 0, nil)
+		width = max(width, uniseg.StringWidth(item))
+	}
+
+	// Anchor at the start of the word, from the cursor's place in the
+	// text and how far the input has scrolled.
+	x, y, w, _ := s.query.GetInnerRect()
+	x += uniseg.StringWidth(s.query.GetLabel())
+	_, _, row, col := s.query.GetCursor()
+	rowOffset, colOffset := s.query.GetOffset()
+	left := min(max(x+col-colOffset-uniseg.StringWidth(prefix), x), x+w-1)
+	cy := y + row - rowOffset
+	height := min(len(cands), 10)
+	top := cy + 1
+	if s.screen != nil {
+		if _, sh := s.screen.Size(); top+height > sh {
+			top = cy - height
+		}
+	}
+	c.list.SetRect(left, top, width+2, height)
+}
+
+// narrowCompletion is the query input's changed func: typing at the
+// cursor narrows the open drop-down, or closes it when nothing is left.
+func (s *uiState) narrowCompletion() {
+	if s.completion == nil {
+		return
+	}
+	prefix, cands := s.completions(s.query.GetText(), s.queryCursor())
+	if len(cands) == 0 {
+		s.closeCompletion()
+		return
+	}
+	s.openCompletion(prefix, cands)
+}
+
+// acceptCompletion replaces the word at the cursor with candidate i.
+func (s *uiState) acceptCompletion(i int) {
+	c := s.completion
+	if c == nil || i < 0 || i >= len(c.items) {
+		return
+	}
+	s.closeCompletion()
+	s.insertCompletion(s.queryCursor(), c.prefix, c.items[i])
+}
+
+// insertCompletion puts item in place of prefix, which ends at cursor;
+// the input leaves the cursor after it.
+func (s *uiState) insertCompletion(cursor int, prefix, item string) {
+	s.query.Replace(cursor-len(prefix), cursor, item)
+}
+
+func (s *uiState) closeCompletion() {
+	if s.completion == nil {
+		return
+	}
+	s.completion = nil
+	focused := s.app.GetFocus() == s.query
+	s.pages.RemovePage("completion")
+	if focused {
+		s.app.SetFocus(s.query) // RemovePage focuses the main page
+	}
+}
+
+// completionInput routes keys to the drop-down while it is open, and
+// runs or completes the query otherwise.
+func (s *uiState) completionInput(ev *tcell.EventKey) *tcell.EventKey {
+	if s.completion == nil {
+		if id := s.keys.action(ev, "query"); id != "" {
+			s.runAction(id)
+			return nil
+		}
+		return ev
+	}
+
+	list := s.completion.list
+	switch ev.Key() {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+		list.InputHandler()(ev, func(tview.Primitive) {})
+		return nil
+	case tcell.KeyTab, tcell.KeyEnter:
+		s.acceptCompletion(list.GetCurrentItem())
+		return nil
+	case tcell.KeyEsc:
+		s.closeCompletion()
+		return nil
+	case tcell.KeyRune, tcell.KeyBackspace, tcell.KeyBackspace2:
+		return ev // typing at the cursor narrows the drop-down
+	}
+	// Anything else may move the cursor away from the word.
+	s.closeCompletion()
+	return ev
+}
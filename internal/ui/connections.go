@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// toggleReadOnly switches read-only mode: only a single SELECT (or WITH
+// … SELECT) runs, anything else is refused before it reaches the server.
+// Where the adapter supports it the server backs this up, running each
+// query in a read-only or rolled-back transaction.
+func (s *uiState) toggleReadOnly() {
+	s.readOnly = !s.readOnly
+	if ro, ok := db.Unwrap(s.db).(db.ReadOnlySetter); ok {
+		ro.SetReadOnly(s.readOnly)
+	}
+	s.header.SetText(s.headerText())
+	if s.readOnly {
+		s.setStatus(greenTag + "Read-only:[-] only SELECT statements run.")
+	} else {
+		s.setStatus(yellowTag + "Read-only off:[-] all statements run.")
+	}
+}
+
+// allowed reports whether sql may run, and says why not in the status
+// bar.
+func (s *uiState) allowed(sql string) bool {
+	if !s.readOnly || sqllex.IsSelect(sql, s.dialect) {
+		return true
+	}
+	s.setStatus(fmt.Sprintf(yellowTag+"Read-only:[-] only a single SELECT runs "+dimTag+"(%s to allow writes)[-]",
+		tview.Escape(s.keys.label("global.read-only"))))
+	return false
+}
+
+// connDrivers are the drivers the connection form offers; "detect" has
+// the app layer tell from the DSN.
+var connDrivers = []string{"detect", "sqlite", "postgres", "mysql", "mssql"}
+
+// showConnections lists the saved connections and "Other DSN…";
+// picking one quits the TUI and has the app layer open it (see
+// Options.Open). Without saved connections it goes straight to the
+// driver and DSN form.
+func (s *uiState) showConnections() {
+	if s.open == nil {
+		s.setStatus(dimTag + "Opening another connection is not available here.[-]")
+		return
+	}
+	if len(s.connections) == 0 {
+		s.showConnectionForm()
+		return
+	}
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	themeSelection(list)
+	for _, name := range s.connections {
+		list.AddItem(tview.Escape(name), "", 0, nil)
+	}
+	list.AddItem(dimTag+"Other DSN…[-]", "", 0, nil)
+	list.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		if i == len(s.connections) {
+			s.showConnectionForm()
+			return
+		}
+		s.open(Connection{Name: s.connections[i]})
+		s.app.Stop()
+	})
+
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(dimTag + "Enter[-] open  " + s.keys.hint("overlay.close-viewer", "close"))
+	s.showConnectionPage(list, help)
+}
+
+// showConnectionForm asks for a driver and DSN to open.
+func (s *uiState) showConnectionForm() {
+	form := tview.NewForm().
+		AddDropDown("Driver", connDrivers, 0, nil).
+		AddInputField("DSN", "", 0, nil, nil)
+	form.AddButton("Open", func() {
+		i, driver := form.GetFormItemByLabel("Driver").(*tview.DropDown).GetCurrentOption()
+		if i == 0 {
+			driver = ""
+		}
+		dsn := strings.TrimSpace(form.GetFormItemByLabel("DSN").(*tview.InputField).GetText())
+		if dsn == "" {
+			s.setStatus(yellowTag + "Opening a connection needs a DSN or file.[-]")
+			return
+		}
+		s.open(Connection{Driver: driver, DSN: dsn})
+		s.app.Stop()
+	})
+	form.SetCancelFunc(func() {
+		s.pages.RemovePage("connections")
+		s.app.SetFocus(s.result)
+	})
+
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(dimTag + "Tab[-] next field  " + dimTag + "Esc[-] close")
+	s.showConnectionPage(form, help)
+}
+
+// showConnectionPage shows p with help under it as the "connections"
+// overlay.
+func (s *uiState) showConnectionPage(p tview.Primitive, help *tview.TextView) {
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(" Open connection ").
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("connections", centered(frame), true)
+	s.app.SetFocus(p)
+}
@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// hotNodes is how many of the most expensive operators get highlighted.
+const hotNodes = 3
+
+// explainCurrentQuery runs the query input through the driver's plan
+// command and shows the result as a tree. analyze executes the statement
+// (inside a rolled-back transaction) to get actual timings.
+func (s *uiState) explainCurrentQuery(analyze bool) {
+	query := strings.TrimSpace(s.query.GetText())
+	if query == "" {
+		s.setStatus(yellowTag + "Nothing to explain – type a query first.[-]")
+		return
+	}
+	// ANALYZE executes the statement, if only in a rolled-back transaction.
+	if analyze && !s.allowed(query) {
+		return
+	}
+	explainer, ok := db.Unwrap(s.db).(db.Explainer)
+	if !ok {
+		s.setStatus(fmt.Sprintf(yellowTag+"EXPLAIN is not available for %s.[-]", s.label))
+		return
+	}
+
+	mode := "estimated"
+	if analyze {
+		mode = "ANALYZE, rolled back"
+	}
+	s.setStatus(fmt.Sprintf(yellowTag+"Explaining (%s)…[-]", mode))
+
+	start := time.Now()
+	plan, err := explainer.Explain(s.ctx, query, analyze)
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"EXPLAIN error:[-] %v", err))
+		return
+	}
+	s.setStatus(fmt.Sprintf(greenTag+"Plan OK[-] "+dimTag+"(%s, %s)[-]", mode, time.Since(start).Truncate(time.Millisecond)))
+	s.showPlan(plan, analyze)
+}
+
+// selfCost is a node's own share: time when analyzed, else cost, minus
+// what its children account for.
+func selfCost(n *db.PlanNode, analyze bool) float64 {
+	total := n.Cost
+	if analyze && n.ActualMs > 0 {
+		total = n.ActualMs
+	}
+	for _, c := range n.Children {
+		if analyze && c.ActualMs > 0 {
+			total -= c.ActualMs
+		} else {
+			total -= c.Cost
+		}
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
+// hottest returns the hotNodes nodes with the highest self cost.
+func hottest(root *db.PlanNode, analyze bool) map[*db.PlanNode]bool {
+	var all []*db.PlanNode
+	var walk func(n *db.PlanNode)
+	walk = func(n *db.PlanNode) {
+		all = append(all, n)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return selfCost(all[i], analyze) > selfCost(all[j], analyze)
+	})
+	out := map[*db.PlanNode]bool{}
+	for i := 0; i < len(all) && i < hotNodes; i++ {
+		if selfCost(all[i], analyze) > 0 {
+			out[all[i]] = true
+		}
+	}
+	return out
+}
+
+func planLabel(n *db.PlanNode, hot bool) string {
+	var b strings.Builder
+	op := tview.Escape(n.Op)
+	switch {
+	case n.FullScan:
+		b.WriteString("[" + planScanColor + "::b]" + op + "[-::-]")
+	case hot:
+		b.WriteString("[" + planCostColor + "::b]" + op + "[-::-]")
+	default:
+		b.WriteString("[::b]" + op + "[::-]")
+	}
+	if n.Target != "" {
+		b.WriteString(" on [" + planTargetColor + "]" + tview.Escape(n.Target) + "[-]")
+	}
+
+	var stats []string
+	if n.Cost > 0 {
+		stats = append(stats, "cost="+formatPlanNum(n.Cost))
+	}
+	if n.Rows > 0 {
+		stats = append(stats, "rows="+formatPlanNum(n.Rows))
+	}
+	if n.ActualMs > 0 || n.Loops > 0 {
+		stats = append(stats, fmt.Sprintf("actual=%sms rows=%s loops=%s",
+			formatPlanNum(n.ActualMs), formatPlanNum(n.ActualRows), formatPlanNum(n.Loops)))
+	}
+	if len(stats) > 0 {
+		b.WriteString("  " + dimTag + strings.Join(stats, " ") + "[-]")
+	}
+	if n.Detail != "" {
+		b.WriteString("  [" + planDetailColor + "]" + tview.Escape(truncateInline(n.Detail, 120)) + "[-]")
+	}
+	return b.String()
+}
+
+func formatPlanNum(f float64) string {
+	if f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func buildPlanTree(n *db.PlanNode, hot map[*db.PlanNode]bool) *tview.TreeNode {
+	node := tview.NewTreeNode(planLabel(n, hot[n])).
+		SetReference(n).
+		SetSelectable(true)
+	for _, c := range n.Children {
+		node.AddChild(buildPlanTree(c, hot))
+	}
+	return node
+}
+
+func (s *uiState) showPlan(plan *db.PlanNode, analyze bool) {
+	root := buildPlanTree(plan, hottest(plan, analyze))
+	tree := tview.NewTreeView().
+		SetRoot(root).
+		SetCurrentNode(root)
+	themeSelection(tree)
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+	tree.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if s.keys.is(ev, "plan.analyze") && !analyze {
+			s.pages.RemovePage("planView")
+			s.explainCurrentQuery(true)
+			return nil
+		}
+		return ev
+	})
+
+	legend := fmt.Sprintf("[%s]■[-] full scan  [%s]■[-] most expensive  "+dimTag+"Enter[-] fold  %s",
+		planScanColor, planCostColor, s.keys.hint("overlay.close-viewer", "close"))
+	if !analyze {
+		legend += "  " + s.keys.hint("plan.analyze", "re-run with ANALYZE (executes, then rolls back)")
+	}
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(legend)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	title := " Query plan (estimated) "
+	if analyze {
+		title = " Query plan (ANALYZE, rolled back) "
+	}
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(title).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("planView", centered(frame), true)
+	s.app.SetFocus(tree)
+}
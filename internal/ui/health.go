@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db/health"
+)
+
+// healthText is the header's connection indicator: "● 3ms",
+// "◌ reconnecting…" or "✕ disconnected".
+func healthText(st health.Status) string {
+	switch st.State {
+	case health.Reconnecting:
+		return yellowTag + "◌ reconnecting…[-]"
+	case health.Disconnected:
+		return redTag + "[::b]✕ disconnected[-::-]"
+	}
+	if st.Latency == 0 {
+		return greenTag + "●[-] connected"
+	}
+	return greenTag + "●[-] " + formatLatency(st.Latency)
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1ms"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// onHealthChange runs (on the UI goroutine) after each health check and
+// when a query finds the connection broken.
+func (s *uiState) onHealthChange() {
+	if s.header != nil {
+		s.header.SetText(s.headerText())
+	}
+	st := s.health.Status()
+	prev := s.lastState
+	s.lastState = st.State
+	if st.State == prev {
+		return
+	}
+	switch st.State {
+	case health.Connected:
+		s.setStatus(fmt.Sprintf(greenTag+"Reconnected[-] "+dimTag+"(%s)[-]", formatLatency(st.Latency)))
+	case health.Reconnecting:
+		if st.Err != nil {
+			s.setStatus(yellowTag + "Connection lost, reconnecting…[-] " + tview.Escape(st.Err.Error()))
+		}
+	case health.Disconnected:
+		s.setStatus(fmt.Sprintf(redTag+"Disconnected:[-] %s "+dimTag+"(%s to retry now)[-]",
+			tview.Escape(st.Err.Error()), s.keys.label("global.reconnect")))
+	}
+}
+
+// reconnect drops idle connections and checks the server right away.
+func (s *uiState) reconnect() {
+	if s.health == nil || !s.health.Watched() {
+		s.setStatus(dimTag + "Nothing to reconnect: this database has no server connection.[-]")
+		return
+	}
+	s.setStatus(yellowTag + "Reconnecting…[-]")
+	go s.health.Reconnect() // its change callback queues a redraw
+
+}
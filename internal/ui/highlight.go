@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// highlightQuery recolors the query input after tview has drawn it.
+// TextArea has no notion of styled text, so we lay the text out the way
+// it does without wrapping (a row per line, shifted by its scroll
+// offset) and repaint each visible cell with its token color.
+func (s *uiState) highlightQuery(screen tcell.Screen) {
+	if front, _ := s.pages.GetFrontPage(); front != "main" && front != "completion" {
+		return
+	}
+	text := s.query.GetText()
+	if text == "" {
+		return
+	}
+	x, y, w, h := s.query.GetInnerRect()
+	label := uniseg.StringWidth(s.query.GetLabel())
+	x, w = x+label, w-label
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	colors := make([]string, len(text))
+	for _, t := range sqllex.Lex(text, s.dialect) {
+		if c, ok := sqllex.Colors[t.Kind]; ok {
+			for i := t.Start; i < t.End; i++ {
+				colors[i] = c
+			}
+		}
+	}
+
+	rowOffset, colOffset := s.query.GetOffset()
+	row, col, pos, state := 0, 0, 0, -1
+	for rest := text; rest != ""; {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		width := boundaries >> uniseg.ShiftWidth
+		if cluster == "\t" {
+			width = tview.TabSize
+		}
+
+		cx, cy := col-colOffset, row-rowOffset
+		if c := colors[pos]; c != "" && width > 0 && cx >= 0 && cx+width <= w && cy >= 0 && cy < h {
+			str, style, _ := screen.Get(x+cx, y+cy)
+			screen.Put(x+cx, y+cy, str, style.Foreground(tcell.GetColor(c)))
+		}
+
+		pos += len(cluster)
+		col += width
+		if boundaries&uniseg.MaskLine == uniseg.LineMustBreak {
+			row, col = row+1, 0
+		}
+	}
+}
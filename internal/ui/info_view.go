@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// showInfo asks the adapter about the server and the session and lists
+// what it says.
+func (s *uiState) showInfo() {
+	reporter, ok := db.Unwrap(s.db).(db.InfoReporter)
+	if !ok {
+		s.setStatus(fmt.Sprintf(yellowTag+"Server info is not available for %s.[-]", s.label))
+		return
+	}
+	s.setStatus(yellowTag + "Loading server info…[-]")
+	info, err := reporter.ServerInfo(s.ctx)
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Server info failed:[-] %v", err))
+		return
+	}
+	s.setStatus(greenTag + "Server info loaded.[-]")
+
+	grid := tview.NewTable().
+		SetBorders(false)
+	row := 0
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		grid.SetCell(row, 0, tview.NewTableCell(name).SetAttributes(tcell.AttrBold))
+		grid.SetCell(row, 1, tview.NewTableCell(value).SetExpansion(1))
+		row++
+	}
+	section := func(title string) {
+		if row > 0 {
+			row++ // blank line
+		}
+		grid.SetCell(row, 0, tview.NewTableCell(title).
+			SetTextColor(tview.Styles.SecondaryTextColor).
+			SetSelectable(false))
+		row++
+	}
+
+	section("server")
+	// Version strings can span lines (SQL Server's does).
+	for i, line := range strings.Split(strings.TrimSpace(info.Version), "\n") {
+		name := ""
+		if i == 0 {
+			name = "version"
+		}
+		grid.SetCell(row, 0, tview.NewTableCell(name).SetAttributes(tcell.AttrBold))
+		grid.SetCell(row, 1, tview.NewTableCell(strings.TrimSpace(line)).SetExpansion(1))
+		row++
+	}
+	add("time zone", info.TimeZone)
+	add("encoding", info.Encoding)
+
+	section("database")
+	add("database", info.Database)
+	add("schema", info.Schema)
+	if info.Size >= 0 {
+		add("size", formatSize(info.Size))
+	}
+
+	section("session")
+	add("user", info.User)
+	add("roles", strings.Join(info.Roles, ", "))
+	add("connection id", info.ConnectionID)
+	for _, st := range info.Settings {
+		if st.Value == "" {
+			st.Value = "(empty)" // e.g. an empty sql_mode, which is meaningful
+		}
+		add(st.Name, st.Value)
+	}
+	grid.SetSelectable(true, false)
+	themeSelection(grid)
+
+	frame := tview.NewFrame(grid).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Server info: %s ", s.label)).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("info", centered(frame), true)
+	s.app.SetFocus(grid)
+}
+
+// formatSize prints a byte count in binary units: "512 B", "3.4 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,598 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// jsonKind is the JSON value type of a node.
+type jsonKind int
+
+const (
+	jsonObject jsonKind = iota
+	jsonArray
+	jsonString
+	jsonNumber
+	jsonBool
+	jsonNull
+)
+
+// jsonPathElem is one step in a path from the document root:
+// either an object key or an array index.
+type jsonPathElem struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// jsonNode is an order-preserving JSON tree. encoding/json maps lose
+// key order, which makes the viewer jump around, so we decode by token.
+type jsonNode struct {
+	kind     jsonKind
+	value    string // scalars only (raw literal for numbers/bools)
+	path     []jsonPathElem
+	keys     []string // object keys, parallel to children
+	children []*jsonNode
+}
+
+// isJSONColumn reports whether a value should be treated as JSON, either
+// because the column type says so or because it parses as an object/array.
+func isJSONColumn(col db.Column, v any) bool {
+	s, ok := jsonText(v)
+	if !ok {
+		return false
+	}
+	if strings.Contains(strings.ToLower(col.Type), "json") {
+		return json.Valid([]byte(s))
+	}
+	t := strings.TrimSpace(s)
+	if len(t) < 2 {
+		return false
+	}
+	if (t[0] == '{' && t[len(t)-1] == '}') || (t[0] == '[' && t[len(t)-1] == ']') {
+		return json.Valid([]byte(t))
+	}
+	return false
+}
+
+func jsonText(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	default:
+		return "", false
+	}
+}
+
+func parseJSONTree(s string) (*jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	root, err := decodeJSONNode(dec, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return root, nil
+}
+
+func decodeJSONNode(dec *json.Decoder, path []jsonPathElem) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &jsonNode{path: path}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			n.kind = jsonObject
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := kt.(string)
+				child, err := decodeJSONNode(dec, appendPath(path, jsonPathElem{key: key}))
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.children = append(n.children, child)
+			}
+		case '[':
+			n.kind = jsonArray
+			for i := 0; dec.More(); i++ {
+				child, err := decodeJSONNode(dec, appendPath(path, jsonPathElem{index: i, isIdx: true}))
+				if err != nil {
+					return nil, err
+				}
+				n.children = append(n.children, child)
+			}
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind = jsonString
+		n.value = t
+	case json.Number:
+		n.kind = jsonNumber
+		n.value = t.String()
+	case bool:
+		n.kind = jsonBool
+		n.value = strconv.FormatBool(t)
+	case nil:
+		n.kind = jsonNull
+		n.value = "null"
+	}
+	return n, nil
+}
+
+func appendPath(path []jsonPathElem, e jsonPathElem) []jsonPathElem {
+	out := make([]jsonPathElem, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, e)
+}
+
+// parseJSONPath parses a small jq-like path: ".a.b[0]", "a.b.0", `."odd key"`.
+func parseJSONPath(p string) ([]jsonPathElem, error) {
+	var out []jsonPathElem
+	p = strings.TrimSpace(p)
+	i := 0
+	for i < len(p) {
+		switch c := p[i]; {
+		case c == '.':
+			i++
+		case c == '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in path")
+			}
+			inner := strings.TrimSpace(p[i+1 : i+end])
+			i += end + 1
+			if n, err := strconv.Atoi(inner); err == nil {
+				out = append(out, jsonPathElem{index: n, isIdx: true})
+				continue
+			}
+			key, err := strconv.Unquote(inner)
+			if err != nil {
+				return nil, fmt.Errorf("bad path segment %q", inner)
+			}
+			out = append(out, jsonPathElem{key: key})
+		case c == '"':
+			end := i + 1
+			for end < len(p) && (p[end] != '"' || p[end-1] == '\\') {
+				end++
+			}
+			if end >= len(p) {
+				return nil, fmt.Errorf("unclosed quote in path")
+			}
+			key, err := strconv.Unquote(p[i : end+1])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, jsonPathElem{key: key})
+			i = end + 1
+		default:
+			end := i
+			for end < len(p) && p[end] != '.' && p[end] != '[' {
+				end++
+			}
+			seg := p[i:end]
+			i = end
+			if n, err := strconv.Atoi(seg); err == nil {
+				out = append(out, jsonPathElem{index: n, isIdx: true})
+			} else {
+				out = append(out, jsonPathElem{key: seg})
+			}
+		}
+	}
+	return out, nil
+}
+
+// lookup walks path from n. Numeric segments also match object keys
+// so "a.0" works on {"a":{"0":1}}.
+func (n *jsonNode) lookup(path []jsonPathElem) *jsonNode {
+	cur := n
+	for _, e := range path {
+		var next *jsonNode
+		switch cur.kind {
+		case jsonArray:
+			if e.isIdx && e.index >= 0 && e.index < len(cur.children) {
+				next = cur.children[e.index]
+			}
+		case jsonObject:
+			key := e.key
+			if e.isIdx {
+				key = strconv.Itoa(e.index)
+			}
+			for i, k := range cur.keys {
+				if k == key {
+					next = cur.children[i]
+					break
+				}
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+// jqPath renders a path in the same syntax the filter box accepts.
+func jqPath(path []jsonPathElem) string {
+	if len(path) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for _, e := range path {
+		switch {
+		case e.isIdx:
+			fmt.Fprintf(&b, "[%d]", e.index)
+		case isPlainJSONKey(e.key):
+			b.WriteString(".")
+			b.WriteString(e.key)
+		default:
+			b.WriteString(".")
+			b.WriteString(jsonQuote(e.key))
+		}
+	}
+	return b.String()
+}
+
+// sqlJSONPath renders a "$.a.b[0]" path as used by MySQL, SQL Server and SQLite.
+func sqlJSONPath(path []jsonPathElem) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, e := range path {
+		switch {
+		case e.isIdx:
+			fmt.Fprintf(&b, "[%d]", e.index)
+		case isPlainJSONKey(e.key):
+			b.WriteString(".")
+			b.WriteString(e.key)
+		default:
+			b.WriteString(".")
+			b.WriteString(jsonQuote(e.key))
+		}
+	}
+	return b.String()
+}
+
+// jsonQuote writes k as a JSON string, the form jq and SQL JSON paths
+// take for keys that are not plain identifiers.
+func jsonQuote(k string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(k)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func isPlainJSONKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i, r := range k {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// jsonAccessor builds the driver-specific SQL expression that extracts
+// the value at path from column col (quoted as an identifier). scalar
+// selects the text-returning form where the dialect has one.
+func jsonAccessor(driver, col string, path []jsonPathElem, scalar bool) string {
+	d := sqllex.DialectFor(driver)
+	sqlStr := func(s string) string { return sqllex.QuoteString(s, d) }
+	col = sqllex.QuoteIdent(col, d)
+
+	switch driver {
+	case "postgres":
+		if len(path) == 0 {
+			return col
+		}
+		var b strings.Builder
+		b.WriteString(col)
+		for i, e := range path {
+			op := "->"
+			if scalar && i == len(path)-1 {
+				op = "->>"
+			}
+			b.WriteString(op)
+			if e.isIdx {
+				b.WriteString(strconv.Itoa(e.index))
+			} else {
+				b.WriteString(sqlStr(e.key))
+			}
+		}
+		return b.String()
+	case "mysql":
+		expr := fmt.Sprintf("JSON_EXTRACT(%s, %s)", col, sqlStr(sqlJSONPath(path)))
+		if scalar {
+			return "JSON_UNQUOTE(" + expr + ")"
+		}
+		return expr
+	case "mssql":
+		fn := "JSON_QUERY"
+		if scalar {
+			fn = "JSON_VALUE"
+		}
+		return fmt.Sprintf("%s(%s, %s)", fn, col, sqlStr(sqlJSONPath(path)))
+	default:
+		return fmt.Sprintf("json_extract(%s, %s)", col, sqlStr(sqlJSONPath(path)))
+	}
+}
+
+// prettyJSON indents s and adds tview color tags. Falls back to the
+// escaped input if it does not parse.
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return tview.Escape(s)
+	}
+	return highlightJSON(buf.String())
+}
+
+// highlightJSON colors already-indented JSON text line by line.
+func highlightJSON(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				end = len(s) - 1
+			}
+			lit := s[i : end+1]
+			// a string followed by ':' is a key
+			rest := strings.TrimLeft(s[end+1:], " ")
+			color := jsonStringColor
+			if strings.HasPrefix(rest, ":") {
+				color = jsonKeyColor
+			}
+			b.WriteString("[" + color + "]")
+			b.WriteString(tview.Escape(lit))
+			b.WriteString("[-]")
+			i = end + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(s) && strings.IndexByte("+-.eE0123456789", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString("[" + jsonNumberColor + "]" + s[i:end] + "[-]")
+			i = end
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "false"), strings.HasPrefix(s[i:], "null"):
+			end := i
+			for end < len(s) && s[end] >= 'a' && s[end] <= 'z' {
+				end++
+			}
+			b.WriteString("[" + jsonLiteralColor + "]" + s[i:end] + "[-]")
+			i = end
+		default:
+			if c == '[' || c == ']' {
+				b.WriteString(tview.Escape(string(c)))
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		}
+	}
+	return b.String()
+}
+
+func (n *jsonNode) scalarLabel() string {
+	switch n.kind {
+	case jsonString:
+		return "[" + jsonStringColor + "]" + tview.Escape(strconv.Quote(n.value)) + "[-]"
+	case jsonNumber:
+		return "[" + jsonNumberColor + "]" + n.value + "[-]"
+	default:
+		return "[" + jsonLiteralColor + "]" + n.value + "[-]"
+	}
+}
+
+func (n *jsonNode) containerLabel() string {
+	switch n.kind {
+	case jsonObject:
+		return fmt.Sprintf("{…} "+dimTag+"%d keys[-]", len(n.children))
+	default:
+		return fmt.Sprintf("[…] "+dimTag+"%d items[-]", len(n.children))
+	}
+}
+
+// buildTreeNode converts n into tview tree nodes. name is the key/index
+// shown before the value ("" for the root).
+func buildTreeNode(n *jsonNode, name string) *tview.TreeNode {
+	prefix := ""
+	if name != "" {
+		prefix = name + ": "
+	}
+
+	if n.kind != jsonObject && n.kind != jsonArray {
+		return tview.NewTreeNode(prefix + n.scalarLabel()).
+			SetReference(n).
+			SetSelectable(true)
+	}
+
+	node := tview.NewTreeNode(prefix + n.containerLabel()).
+		SetReference(n).
+		SetSelectable(true).
+		SetColor(tview.Styles.PrimaryTextColor)
+
+	for i, child := range n.children {
+		childName := fmt.Sprintf(dimTag+"%d[-]", i)
+		if n.kind == jsonObject {
+			childName = "[" + jsonKeyColor + "]" + tview.Escape(n.keys[i]) + "[-]"
+		}
+		node.AddChild(buildTreeNode(child, childName))
+	}
+	return node
+}
+
+// showJSONViewer opens a collapsible tree view for one JSON cell.
+func (s *uiState) showJSONViewer(col db.Column, raw string) {
+	doc, err := parseJSONTree(raw)
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Invalid JSON:[-] %v", err))
+		return
+	}
+
+	tree := tview.NewTreeView()
+	tree.SetGraphics(true)
+
+	info := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	filter := tview.NewInputField().
+		SetLabel("path ").
+		SetFieldWidth(0).
+		SetPlaceholder(".a.b[0]")
+
+	setRoot := func(n *jsonNode) {
+		name := "[" + jsonKeyColor + "]" + tview.Escape(col.Name) + "[-]"
+		if len(n.path) > 0 {
+			name = "[" + jsonKeyColor + "]" + tview.Escape(jqPath(n.path)) + "[-]"
+		}
+		root := buildTreeNode(n, name)
+		tree.SetRoot(root).SetCurrentNode(root)
+		themeSelection(tree)
+	}
+	setRoot(doc)
+
+	updateInfo := func(node *tview.TreeNode) {
+		if node == nil {
+			return
+		}
+		n, ok := node.GetReference().(*jsonNode)
+		if !ok {
+			return
+		}
+		info.SetText(fmt.Sprintf(dimTag+"%s[-]  %s",
+			tview.Escape(jqPath(n.path)),
+			tview.Escape(jsonAccessor(s.label, col.Name, n.path, n.kind != jsonObject && n.kind != jsonArray)),
+		))
+	}
+	updateInfo(tree.GetRoot())
+
+	tree.SetChangedFunc(updateInfo)
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	filter.SetChangedFunc(func(text string) {
+		text = strings.TrimSpace(text)
+		if text == "" || text == "." {
+			setRoot(doc)
+			filter.SetFieldTextColor(tview.Styles.PrimaryTextColor)
+			return
+		}
+		path, err := parseJSONPath(text)
+		if err != nil {
+			filter.SetFieldTextColor(tcell.ColorRed)
+			return
+		}
+		n := doc.lookup(path)
+		if n == nil {
+			filter.SetFieldTextColor(tcell.ColorRed)
+			return
+		}
+		filter.SetFieldTextColor(tview.Styles.PrimaryTextColor)
+		setRoot(n)
+		updateInfo(tree.GetRoot())
+	})
+	filter.SetDoneFunc(func(key tcell.Key) {
+		s.app.SetFocus(tree)
+	})
+
+	tree.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch s.keys.action(ev, "json") {
+		case "json.filter":
+			s.app.SetFocus(filter)
+			return nil
+		case "json.copy":
+			node := tree.GetCurrentNode()
+			if node == nil {
+				return nil
+			}
+			if n, ok := node.GetReference().(*jsonNode); ok {
+				expr := jsonAccessor(s.label, col.Name, n.path, n.kind != jsonObject && n.kind != jsonArray)
+				s.copyToClipboard(expr)
+				s.setStatus(fmt.Sprintf(greenTag+"Copied:[-] %s", tview.Escape(expr)))
+			}
+			return nil
+		case "json.expand-all":
+			tree.GetRoot().ExpandAll()
+			return nil
+		case "json.collapse-all":
+			tree.GetRoot().CollapseAll()
+			tree.GetRoot().SetExpanded(true)
+			return nil
+		}
+		return ev
+	})
+
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(strings.Join([]string{
+			dimTag + "Enter[-] fold",
+			s.keys.hint("json.expand-all", "expand all"),
+			s.keys.hint("json.collapse-all", "collapse all"),
+			s.keys.hint("json.filter", "path filter"),
+			s.keys.hint("json.copy", "copy SQL accessor"),
+			s.keys.hint("overlay.close-viewer", "close"),
+		}, "  "))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, false).
+		AddItem(tree, 0, 1, true).
+		AddItem(info, 1, 0, false).
+		AddItem(help, 1, 0, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(fmt.Sprintf(" JSON: %s ", tview.Escape(col.Name))).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("jsonView", centered(frame), true)
+	s.app.SetFocus(tree)
+}
+
+// copyToClipboard posts text to the terminal clipboard (OSC 52 where supported).
+func (s *uiState) copyToClipboard(text string) {
+	if s.screen == nil {
+		return
+	}
+	s.screen.SetClipboard([]byte(text))
+}
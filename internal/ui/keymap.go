@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// keyBinding is one parsed key such as "Ctrl+R", "Alt+x", "F5" or "/".
+type keyBinding struct {
+	key  tcell.Key // tcell.KeyRune for printable keys
+	ch   rune
+	ctrl bool
+	alt  bool
+}
+
+var namedKeys = map[string]tcell.Key{
+	"enter":     tcell.KeyEnter,
+	"esc":       tcell.KeyEsc,
+	"escape":    tcell.KeyEsc,
+	"tab":       tcell.KeyTab,
+	"backtab":   tcell.KeyBacktab,
+	"backspace": tcell.KeyBackspace2,
+	"delete":    tcell.KeyDelete,
+	"del":       tcell.KeyDelete,
+	"insert":    tcell.KeyInsert,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+}
+
+// parseKey reads a key string: optional Ctrl+/Alt+ modifiers, then a
+// single character, a key name (Enter, Esc, Tab, PgUp, …), Space or F1–F12.
+func parseKey(s string) (keyBinding, error) {
+	var b keyBinding
+	rest := s
+	for {
+		i := strings.IndexByte(rest, '+')
+		if i <= 0 || i == len(rest)-1 {
+			break
+		}
+		switch strings.ToLower(rest[:i]) {
+		case "ctrl":
+			b.ctrl = true
+		case "alt":
+			b.alt = true
+		default:
+			return b, fmt.Errorf("key %q: unknown modifier %q", s, rest[:i])
+		}
+		rest = rest[i+1:]
+	}
+
+	lower := strings.ToLower(rest)
+	if k, ok := namedKeys[lower]; ok {
+		b.key = k
+		return b, nil
+	}
+	if lower == "space" {
+		b.key, b.ch = tcell.KeyRune, ' '
+		return b, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(lower, "f")); err == nil && lower[0] == 'f' && n >= 1 && n <= 12 {
+		b.key = tcell.KeyF1 + tcell.Key(n-1)
+		return b, nil
+	}
+	if r, size := utf8.DecodeRuneInString(rest); size > 0 && size == len(rest) {
+		if b.ctrl {
+			r = []rune(strings.ToLower(string(r)))[0]
+		}
+		b.key, b.ch = tcell.KeyRune, r
+		return b, nil
+	}
+	return b, fmt.Errorf("unknown key %q", s)
+}
+
+// matches reports whether ev is this key. Ctrl+<letter> also matches the
+// terminal's control code for it, as isCtrlKey does.
+func (b keyBinding) matches(ev *tcell.EventKey) bool {
+	mods := ev.Modifiers()
+	if b.key == tcell.KeyRune && b.ctrl {
+		var code tcell.Key
+		if b.ch >= 'a' && b.ch <= 'z' {
+			code = tcell.KeyCtrlA + tcell.Key(b.ch-'a')
+		}
+		return isCtrlKey(ev, code, b.ch)
+	}
+	if b.alt != (mods&tcell.ModAlt != 0) {
+		return false
+	}
+	if b.key == tcell.KeyRune {
+		return ev.Key() == tcell.KeyRune && ev.Rune() == b.ch && mods&tcell.ModCtrl == 0
+	}
+	return ev.Key() == b.key && b.ctrl == (mods&tcell.ModCtrl != 0)
+}
+
+// keymap is the active binding of every action.
+type keymap struct {
+	keys     map[string][]keyBinding
+	labels   map[string][]string
+	warnings []string // problems in the user's bindings, for the status bar
+}
+
+// newKeymap starts from the defaults and applies overrides (action id ->
+// key strings). Unknown ids and keys are reported in warnings and skipped.
+func newKeymap(overrides map[string][]string) *keymap {
+	k := &keymap{keys: map[string][]keyBinding{}, labels: map[string][]string{}}
+	known := map[string]bool{}
+	for _, a := range actions {
+		known[a.id] = true
+		k.bind(a.id, a.keys)
+	}
+
+	ids := make([]string, 0, len(overrides))
+	for id := range overrides {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if !known[id] {
+			k.warnings = append(k.warnings, fmt.Sprintf("unknown action %q", id))
+			continue
+		}
+		k.bind(id, overrides[id])
+	}
+	return k
+}
+
+func (k *keymap) bind(id string, keys []string) {
+	k.keys[id], k.labels[id] = nil, nil
+	for _, s := range keys {
+		b, err := parseKey(s)
+		if err != nil {
+			k.warnings = append(k.warnings, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		k.keys[id] = append(k.keys[id], b)
+		k.labels[id] = append(k.labels[id], s)
+	}
+}
+
+// is reports whether ev is bound to the action id.
+func (k *keymap) is(ev *tcell.EventKey, id string) bool {
+	for _, b := range k.keys[id] {
+		if b.matches(ev) {
+			return true
+		}
+	}
+	return false
+}
+
+// action returns the first action of scope that ev is bound to, or "".
+func (k *keymap) action(ev *tcell.EventKey, scope string) string {
+	prefix := scope + "."
+	for _, a := range actions {
+		if strings.HasPrefix(a.id, prefix) && k.is(ev, a.id) {
+			return a.id
+		}
+	}
+	return ""
+}
+
+// label is the keys of id for display, e.g. "Ctrl+Q / Ctrl+C".
+func (k *keymap) label(id string) string {
+	if len(k.labels[id]) == 0 {
+		return "(unbound)"
+	}
+	return strings.Join(k.labels[id], " / ")
+}
+
+// hint is a "[gray]key[-] what" legend entry for the viewers.
+func (k *keymap) hint(id, what string) string {
+	return dimTag + tview.Escape(k.label(id)) + "[-] " + what
+}
+
+// helpText renders the help overlay from the active bindings.
+func (k *keymap) helpText() string {
+	var b strings.Builder
+	for _, sc := range keyScopes {
+		b.WriteString("\n[::b]" + sc.title + "[-]\n")
+		prefix := sc.id + "."
+		for _, a := range actions {
+			if !strings.HasPrefix(a.id, prefix) {
+				continue
+			}
+			fmt.Fprintf(&b, "  %-17s %s\n", tview.Escape(k.label(a.id)), tview.Escape(a.help))
+		}
+	}
+	b.WriteString(`
+[::b]Notes[-]
+  ↑ / ↓ move in lists and tables; mouse support is enabled (scroll, click).
+  Keys are rebound in config.json ("keys": {"results.sort": ["S"]}).`)
+	return b.String()
+}
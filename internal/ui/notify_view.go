@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// notifyState holds the LISTEN/NOTIFY page. Listening continues while
+// the page is hidden so nothing is missed between visits.
+type notifyState struct {
+	page     tview.Primitive
+	log      *tview.TextView
+	channels *tview.InputField
+	cancel   context.CancelFunc
+}
+
+// toggleNotify shows or hides the LISTEN/NOTIFY monitor page.
+func (s *uiState) toggleNotify() {
+	notifier, ok := db.Unwrap(s.db).(db.Notifier)
+	if !ok {
+		s.setStatus(fmt.Sprintf(yellowTag+"LISTEN/NOTIFY is not available for %s.[-]", s.label))
+		return
+	}
+
+	if front, _ := s.pages.GetFrontPage(); front == "notify" {
+		s.pages.SwitchToPage("main")
+		s.app.SetFocus(s.result)
+		return
+	}
+
+	if s.notify == nil {
+		s.notify = s.buildNotifyPage(notifier)
+		s.pages.AddPage("notify", s.notify.page, true, false)
+	}
+	s.pages.SwitchToPage("notify")
+	s.app.SetFocus(s.notify.channels)
+}
+
+func (s *uiState) buildNotifyPage(notifier db.Notifier) *notifyState {
+	ns := &notifyState{}
+
+	ns.log = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true)
+	ns.log.SetBorder(true)
+	ns.log.SetTitle(" Notifications ")
+
+	ns.channels = tview.NewInputField().
+		SetLabel("LISTEN ").
+		SetPlaceholder("channel1, channel2 (Enter to apply, empty to stop)").
+		SetFieldWidth(0)
+	ns.channels.SetBorder(true)
+	ns.channels.SetTitle(" Channels ")
+	form := tview.NewForm()
+	ns.channels.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			s.startListening(notifier, splitChannels(ns.channels.GetText()))
+		case tcell.KeyTab, tcell.KeyBacktab:
+			s.app.SetFocus(form)
+		}
+	})
+
+	form.
+		AddInputField("Channel", "", 30, nil, nil).
+		AddInputField("Payload", "", 0, nil, nil)
+	form.AddButton("NOTIFY", func() {
+		ch := strings.TrimSpace(form.GetFormItemByLabel("Channel").(*tview.InputField).GetText())
+		payload := form.GetFormItemByLabel("Payload").(*tview.InputField).GetText()
+		if ch == "" {
+			s.setStatus(yellowTag + "NOTIFY needs a channel.[-]")
+			return
+		}
+		if err := notifier.Notify(s.ctx, ch, payload); err != nil {
+			s.setStatus(fmt.Sprintf(redTag+"NOTIFY failed:[-] %v", err))
+			return
+		}
+		s.setStatus(fmt.Sprintf(greenTag+"Sent NOTIFY on[-] %s", tview.Escape(ch)))
+	})
+	form.SetBorder(true)
+	form.SetTitle(" Send ")
+
+	ns.page = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ns.channels, 3, 0, true).
+		AddItem(ns.log, 0, 1, false).
+		AddItem(form, 9, 0, false).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(" "+dimTag+"Tab[-] next field  "+dimTag+tview.Escape(s.keys.label("global.notify"))+" / Esc[-] back to results"), 1, 0, false)
+
+	return ns
+}
+
+// startListening replaces the current subscription with channels.
+func (s *uiState) startListening(notifier db.Notifier, channels []string) {
+	ns := s.notify
+	if ns.cancel != nil {
+		ns.cancel()
+		ns.cancel = nil
+	}
+	if len(channels) == 0 {
+		ns.log.SetTitle(" Notifications ")
+		s.setStatus(dimTag + "Stopped listening.[-]")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	ns.cancel = cancel
+	ns.log.SetTitle(fmt.Sprintf(" Notifications (%s) ", strings.Join(channels, ", ")))
+	s.setStatus(fmt.Sprintf(greenTag+"Listening on[-] %s", tview.Escape(strings.Join(channels, ", "))))
+
+	go func() {
+		err := notifier.Listen(ctx, channels, func(n db.Notification) {
+			s.app.QueueUpdateDraw(func() {
+				fmt.Fprintf(ns.log, dimTag+"%s[-] ["+notifyChannelColor+"]%s[-] "+dimTag+"pid %d[-]  %s\n",
+					n.Time.Format("15:04:05.000"),
+					tview.Escape(n.Channel),
+					n.PID,
+					tview.Escape(n.Payload),
+				)
+				ns.log.ScrollToEnd()
+			})
+		})
+		if err != nil {
+			s.app.QueueUpdateDraw(func() {
+				s.setStatus(fmt.Sprintf(redTag+"LISTEN failed:[-] %v", err))
+			})
+		}
+	}()
+}
+
+// stopListening is called on shutdown.
+func (s *uiState) stopListening() {
+	if s.notify != nil && s.notify.cancel != nil {
+		s.notify.cancel()
+	}
+}
+
+func splitChannels(text string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (s *uiState) togglePalette() {
+	if front, _ := s.pages.GetFrontPage(); front == "palette" {
+		s.closePalette()
+		return
+	}
+	s.showPalette()
+}
+
+// showPalette opens the command palette: every action that can run from
+// the main screen, fuzzy-filtered as you type, with its current keys.
+// The chosen action runs with focus back where it was.
+func (s *uiState) showPalette() {
+	s.paletteFrom = s.app.GetFocus()
+
+	input := tview.NewInputField().
+		SetLabel("> ").
+		SetFieldWidth(0).
+		SetPlaceholder("type to search actions")
+
+	list := tview.NewTable().
+		SetSelectable(true, false)
+	themeSelection(list)
+
+	var shown []string // action ids, parallel to the list rows
+	render := func(pattern string) {
+		type hit struct {
+			a     *action
+			title string
+			score int
+			pos   []int
+		}
+		var hits []hit
+		for i := range actions {
+			a := &actions[i]
+			if a.run == nil {
+				continue
+			}
+			title := actionTitle(a)
+			score, pos, ok := fuzzyMatch(pattern, title)
+			if !ok {
+				continue
+			}
+			hits = append(hits, hit{a, title, score, pos})
+		}
+		if pattern != "" {
+			sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+		}
+
+		list.Clear()
+		shown = shown[:0]
+		for row, h := range hits {
+			keys := ""
+			if len(s.keys.keys[h.a.id]) > 0 {
+				keys = tview.Escape(s.keys.label(h.a.id))
+			}
+			list.SetCell(row, 0, tview.NewTableCell(highlightMatches(h.title, 0, h.pos)).
+				SetExpansion(1))
+			list.SetCell(row, 1, tview.NewTableCell(keys).
+				SetAlign(tview.AlignRight).
+				SetTextColor(tview.Styles.TertiaryTextColor))
+			shown = append(shown, h.a.id)
+		}
+		list.Select(0, 0).ScrollToBeginning()
+	}
+	render("")
+
+	run := func() {
+		row, _ := list.GetSelection()
+		if row < 0 || row >= len(shown) {
+			return
+		}
+		id := shown[row]
+		s.closePalette()
+		s.runAction(id)
+	}
+	input.SetChangedFunc(render)
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			run()
+		case tcell.KeyEsc:
+			s.closePalette()
+		}
+	})
+	// The list never takes focus; arrows in the input move its selection.
+	input.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch ev.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(ev, func(tview.Primitive) {})
+			return nil
+		}
+		return ev
+	})
+	list.SetSelectedFunc(func(int, int) { run() })
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(" Commands ").
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("palette", centered(frame), true)
+	s.app.SetFocus(input)
+}
+
+func (s *uiState) closePalette() {
+	s.pages.RemovePage("palette")
+	if s.paletteFrom != nil {
+		s.app.SetFocus(s.paletteFrom)
+		s.paletteFrom = nil
+	}
+}
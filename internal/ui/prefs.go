@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// prefs is per-connection UI state that survives restarts.
+type prefs struct {
+	Pinned  []string                 `json:"pinned,omitempty"`  // favourite tables, in pin order
+	Layouts map[string]*columnLayout `json:"layouts,omitempty"` // results grid, per table
+}
+
+// loadPrefs reads path; a missing or unreadable file gives empty prefs.
+func loadPrefs(path string) *prefs {
+	p := &prefs{}
+	if path == "" {
+		return p
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return p
+	}
+	_ = json.Unmarshal(b, p)
+	return p
+}
+
+func (p *prefs) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+func (p *prefs) isPinned(table string) bool {
+	for _, t := range p.Pinned {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// togglePin pins or unpins table and reports the new state.
+func (p *prefs) togglePin(table string) bool {
+	for i, t := range p.Pinned {
+		if t == table {
+			p.Pinned = append(p.Pinned[:i], p.Pinned[i+1:]...)
+			return false
+		}
+	}
+	p.Pinned = append(p.Pinned, table)
+	return true
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/library"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// showQueries opens the saved query browser: the user and project
+// libraries (re-read each time), fuzzy-filtered by name, tags and
+// description, with a preview of the selected query.
+func (s *uiState) showQueries() {
+	all, err := library.Load()
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Saved queries:[-] %v", err))
+		return
+	}
+	var qs []*library.Query
+	for _, q := range all {
+		if q.Matches(s.label) {
+			qs = append(qs, q)
+		}
+	}
+
+	input := tview.NewInputField().
+		SetLabel("> ").
+		SetFieldWidth(0).
+		SetPlaceholder("name, tag or description")
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	themeSelection(list)
+
+	preview := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true).
+		SetWordWrap(true)
+	preview.SetBorder(true).SetTitle(" Preview ")
+
+	var shown []*library.Query // parallel to the list items
+	showPreview := func(i int) {
+		preview.Clear()
+		if i < 0 || i >= len(shown) {
+			return
+		}
+		q := shown[i]
+		var b strings.Builder
+		if q.Description != "" {
+			b.WriteString(tview.Escape(q.Description) + "\n")
+		}
+		fmt.Fprintf(&b, dimTag+"%s library", q.Source)
+		if len(q.Tags) > 0 {
+			b.WriteString(", tags: " + tview.Escape(strings.Join(q.Tags, ", ")))
+		}
+		b.WriteString("[-]\n")
+		for _, p := range q.Params {
+			fmt.Fprintf(&b, dimTag+"param[-] :%s", p.Name)
+			if t := p.Decl(); t != "" {
+				b.WriteString(" " + dimTag + t + "[-]")
+			}
+			if p.HasDefault {
+				b.WriteString(" " + dimTag + "= " + tview.Escape(p.Default) + "[-]")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n" + sqllex.Tview(q.SQL, s.dialect))
+		preview.SetText(b.String())
+		preview.ScrollToBeginning()
+	}
+
+	render := func(pattern string) {
+		type hit struct {
+			q     *library.Query
+			score int
+		}
+		var hits []hit
+		for _, q := range qs {
+			text := q.Name + " " + strings.Join(q.Tags, " ") + " " + q.Description
+			score, _, ok := fuzzyMatch(pattern, text)
+			if !ok {
+				continue
+			}
+			hits = append(hits, hit{q, score})
+		}
+		if pattern != "" {
+			sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+		}
+
+		list.Clear()
+		shown = shown[:0]
+		for _, h := range hits {
+			label := tview.Escape(h.q.Name)
+			if len(h.q.Tags) > 0 {
+				label += " " + dimTag + tview.Escape(strings.Join(h.q.Tags, ",")) + "[-]"
+			}
+			list.AddItem(label, "", 0, nil)
+			shown = append(shown, h.q)
+		}
+		showPreview(0)
+	}
+	list.SetChangedFunc(func(i int, _, _ string, _ rune) { showPreview(i) })
+	render("")
+
+	pick := func(run bool) {
+		i := list.GetCurrentItem()
+		if i < 0 || i >= len(shown) {
+			return
+		}
+		s.pages.RemovePage("queries")
+		s.useSavedQuery(shown[i], run)
+	}
+	input.SetChangedFunc(render)
+	input.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch s.keys.action(ev, "queries") {
+		case "queries.insert":
+			pick(false)
+			return nil
+		case "queries.run":
+			pick(true)
+			return nil
+		}
+		switch ev.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(ev, func(tview.Primitive) {})
+			return nil
+		}
+		return ev
+	})
+	list.SetSelectedFunc(func(int, string, string, rune) { pick(false) })
+
+	empty := ""
+	if len(qs) == 0 {
+		empty = fmt.Sprintf("  "+dimTag+"No saved queries. Add .sql files to %s or .binsql/queries/ in the project.[-]",
+			tview.Escape(library.UserDir()))
+	}
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(strings.Join([]string{
+			s.keys.hint("queries.insert", "insert"),
+			s.keys.hint("queries.run", "run"),
+			s.keys.hint("overlay.close-viewer", "close"),
+		}, "  ") + empty)
+
+	body := tview.NewFlex().
+		AddItem(list, 0, 1, false).
+		AddItem(preview, 0, 2, false)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(body, 0, 1, false).
+		AddItem(help, 1, 0, false)
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(" Saved queries ").
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("queries", centered(frame), true)
+	s.app.SetFocus(input)
+}
+
+// useSavedQuery puts q into the query input, and runs it if run is set.
+// Parameters are asked for first.
+func (s *uiState) useSavedQuery(q *library.Query, run bool) {
+	use := func(values map[string]string) {
+		sql, err := q.Bind(values, s.dialect)
+		if err != nil {
+			s.setStatus(fmt.Sprintf(redTag+"Saved query:[-] %v", err))
+			return
+		}
+		s.query.SetText(sql, true)
+		if run {
+			s.runQuery(sql) // synchronous
+			s.app.SetFocus(s.result)
+			return
+		}
+		s.app.SetFocus(s.query)
+	}
+	if len(q.Params) == 0 {
+		use(nil)
+		return
+	}
+
+	form := tview.NewForm()
+	for _, p := range q.Params {
+		form.AddInputField(p.Name, p.Default, 0, nil, nil)
+	}
+	done := "Insert"
+	if run {
+		done = "Run"
+	}
+	form.AddButton(done, func() {
+		values := map[string]string{}
+		for _, p := range q.Params {
+			values[p.Name] = form.GetFormItemByLabel(p.Name).(*tview.InputField).GetText()
+		}
+		s.pages.RemovePage("queryParams")
+		use(values)
+	})
+	form.AddButton("Cancel", func() {
+		s.pages.RemovePage("queryParams")
+		s.app.SetFocus(s.query)
+	})
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf(" %s: parameters ", q.Name)).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("queryParams", centered(form), true)
+	s.app.SetFocus(form)
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/print"
+)
+
+// exportFileName suggests a file for the current result.
+func (s *uiState) exportFileName() string {
+	name := strings.Trim(s.grid.table, "\"`[]")
+	if name == "" {
+		name = "result"
+	}
+	return name + ".csv"
+}
+
+// exportGrid writes what the grid shows (filtered and sorted rows,
+// visible columns in display order) to path, in the format given by its
+// extension: .csv, .tsv, .json, anything else a text table.
+func (s *uiState) exportGrid(path string) error {
+	path = expandHome(strings.TrimSpace(path))
+	if path == "" || s.lastRows == nil {
+		return nil
+	}
+
+	out := &db.Rows{}
+	for _, c := range s.grid.cols {
+		out.Columns = append(out.Columns, s.lastRows.Columns[c])
+	}
+	for _, i := range s.grid.view {
+		row := s.lastRows.Data[i]
+		cells := make(db.Row, len(s.grid.cols))
+		for j, c := range s.grid.cols {
+			if c < len(row) {
+				cells[j] = row[c]
+			}
+		}
+		out.Data = append(out.Data, cells)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := print.Render(f, out, print.FormatForPath(path), print.Options{}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.setStatus(fmt.Sprintf(greenTag+"Exported %d rows to[-] %s", len(out.Data), tview.Escape(path)))
+	return nil
+}
+
+// expandHome resolves a leading "~/" to the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,436 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// rowPredicate decides whether a result row is shown.
+type rowPredicate func(row db.Row) bool
+
+// parseRowFilter compiles a WHERE-like expression over the result
+// columns, e.g. `status = 'failed' AND amount > 100`. Supported are
+// = != <> < <= > >=, [NOT] LIKE (case-insensitive), IS [NOT] NULL, NOT,
+// AND, OR and parentheses.
+func parseRowFilter(src string, cols []db.Column, d sqllex.Dialect) (rowPredicate, error) {
+	var toks []sqllex.Token
+	for _, t := range sqllex.Lex(src, d) {
+		if t.Kind != sqllex.Whitespace && t.Kind != sqllex.Comment {
+			toks = append(toks, t)
+		}
+	}
+	p := &filterParser{toks: toks, cols: cols}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].Text)
+	}
+	return pred, nil
+}
+
+type filterParser struct {
+	toks []sqllex.Token
+	pos  int
+	cols []db.Column
+}
+
+func (p *filterParser) peek() (sqllex.Token, bool) {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos], true
+	}
+	return sqllex.Token{}, false
+}
+
+// word consumes the next token if it is the keyword kw.
+func (p *filterParser) word(kw string) bool {
+	if t, ok := p.peek(); ok && strings.EqualFold(t.Text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) or() (rowPredicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.word("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(row db.Row) bool { return l(row) || right(row) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (rowPredicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.word("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(row db.Row) bool { return l(row) && right(row) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (rowPredicate, error) {
+	if p.word("NOT") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(row db.Row) bool { return !inner(row) }, nil
+	}
+	if p.word("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.word(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) column() (int, error) {
+	t, ok := p.peek()
+	if !ok {
+		return 0, fmt.Errorf("expected a column name")
+	}
+	switch t.Kind {
+	case sqllex.Ident, sqllex.QuotedIdent, sqllex.Keyword, sqllex.Function:
+	default:
+		return 0, fmt.Errorf("expected a column name, got %q", t.Text)
+	}
+	name := unquoteIdent(t.Text)
+	for i, c := range p.cols {
+		if strings.EqualFold(c.Name, name) {
+			p.pos++
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no column %q in the result", name)
+}
+
+func (p *filterParser) comparison() (rowPredicate, error) {
+	col, err := p.column()
+	if err != nil {
+		return nil, err
+	}
+	cell := func(row db.Row) any {
+		if col < len(row) {
+			return row[col]
+		}
+		return nil
+	}
+
+	if p.word("IS") {
+		negate := p.word("NOT")
+		if !p.word("NULL") {
+			return nil, fmt.Errorf("expected NULL after IS")
+		}
+		return func(row db.Row) bool { return (cell(row) == nil) != negate }, nil
+	}
+
+	negate := p.word("NOT")
+	if p.word("LIKE") || p.word("ILIKE") {
+		lit, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		re, err := likePattern(fmt.Sprint(lit))
+		if err != nil {
+			return nil, err
+		}
+		return func(row db.Row) bool {
+			v := cell(row)
+			return v != nil && re.MatchString(formatValue(v)) != negate
+		}, nil
+	}
+	if negate {
+		return nil, fmt.Errorf("expected LIKE after NOT")
+	}
+
+	t, ok := p.peek()
+	if !ok || t.Kind != sqllex.Operator {
+		return nil, fmt.Errorf("expected an operator after %s", p.cols[col].Name)
+	}
+	op := t.Text
+	switch op {
+	case "=", "==", "!=", "<>", "<", "<=", ">", ">=":
+		p.pos++
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+
+	lit, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	if lit == nil {
+		// "= NULL" is almost always meant as IS NULL in a quick filter.
+		switch op {
+		case "=", "==":
+			return func(row db.Row) bool { return cell(row) == nil }, nil
+		case "!=", "<>":
+			return func(row db.Row) bool { return cell(row) != nil }, nil
+		}
+		return nil, fmt.Errorf("cannot compare with NULL using %s", op)
+	}
+
+	return func(row db.Row) bool {
+		v := cell(row)
+		if v == nil {
+			return false
+		}
+		c, ok := compareLiteral(v, lit)
+		if !ok {
+			return op == "!=" || op == "<>"
+		}
+		switch op {
+		case "=", "==":
+			return c == 0
+		case "!=", "<>":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}, nil
+}
+
+// literal parses a string, number (with optional sign), TRUE/FALSE or
+// NULL (returned as nil).
+func (p *filterParser) literal() (any, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("expected a value")
+	}
+	p.pos++
+	switch {
+	case t.Kind == sqllex.String:
+		s := t.Text
+		if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') {
+			q := s[:1]
+			s = strings.ReplaceAll(s[1:len(s)-1], q+q, q)
+		}
+		return s, nil
+	case t.Kind == sqllex.Number:
+		return strconv.ParseFloat(t.Text, 64)
+	case t.Kind == sqllex.Operator && (t.Text == "-" || t.Text == "+"):
+		n, ok := p.peek()
+		if !ok || n.Kind != sqllex.Number {
+			return nil, fmt.Errorf("expected a number after %s", t.Text)
+		}
+		p.pos++
+		return strconv.ParseFloat(t.Text+n.Text, 64)
+	case strings.EqualFold(t.Text, "NULL"):
+		return nil, nil
+	case strings.EqualFold(t.Text, "TRUE"):
+		return true, nil
+	case strings.EqualFold(t.Text, "FALSE"):
+		return false, nil
+	}
+	return nil, fmt.Errorf("expected a value, got %q", t.Text)
+}
+
+// likePattern turns a SQL LIKE pattern into a case-insensitive regexp.
+func likePattern(pat string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pat {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compareLiteral compares a cell with a filter literal. ok is false when
+// the two cannot be compared (text vs. number).
+func compareLiteral(v, lit any) (int, bool) {
+	switch l := lit.(type) {
+	case float64:
+		n, ok := numericValue(v)
+		if !ok {
+			return 0, false
+		}
+		return compareFloat(n, l), true
+	case bool:
+		b, ok := boolValue(v)
+		if !ok {
+			return 0, false
+		}
+		if b == l {
+			return 0, true
+		}
+		return 1, true
+	case string:
+		if t, ok := timeValue(v); ok {
+			if lt, ok := timeValue(l); ok {
+				return t.Compare(lt), true
+			}
+		}
+		if n, ok := numericValue(v); ok {
+			if ln, ok := numericValue(l); ok {
+				return compareFloat(n, ln), true
+			}
+		}
+		return strings.Compare(formatValue(v), l), true
+	}
+	return 0, false
+}
+
+// compareCells orders two result values: NULLs first, then numbers and
+// times by value, everything else as case-insensitive text.
+func compareCells(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := numericValue(a); ok {
+		if y, ok := numericValue(b); ok {
+			return compareFloat(x, y)
+		}
+	}
+	if x, ok := timeValue(a); ok {
+		if y, ok := timeValue(b); ok {
+			return x.Compare(y)
+		}
+	}
+	sa, sb := formatValue(a), formatValue(b)
+	if c := strings.Compare(strings.ToLower(sa), strings.ToLower(sb)); c != 0 {
+		return c
+	}
+	return strings.Compare(sa, sb)
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func numericValue(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	case []byte:
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(n)), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func boolValue(v any) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		switch strings.ToLower(b) {
+		case "true", "t", "1":
+			return true, true
+		case "false", "f", "0":
+			return false, true
+		}
+	}
+	if n, ok := numericValue(v); ok {
+		return n != 0, true
+	}
+	return false, false
+}
+
+// timeLayouts are the textual date/time forms the drivers return.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func timeValue(v any) (time.Time, bool) {
+	var s string
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		return time.Time{}, false
+	}
+	s = strings.TrimSpace(s)
+	if len(s) < len("2006-01-02") || s[4] != '-' {
+		return time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,435 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
+	"github.com/bgunnarsson/binsql/internal/db"
+)
+
+// gridState is how the current result is presented: which rows are shown
+// in which order, and the active search. All of it is client-side; the
+// query is never re-run.
+type gridState struct {
+	view     []int // indices into lastRows.Data, in display order
+	sortCol  int   // -1: query order
+	sortDesc bool
+	filter   string
+	keep     rowPredicate
+	search   string
+	matches  []gridPos // cells containing search, in display order
+
+	table  string        // table the result reads, if known (layout key)
+	layout *columnLayout // hidden/ordered/frozen/sized columns
+	cols   []int         // visible data columns, in display order
+
+	transposed bool // columns as rows, records as columns
+
+	prompt    *tview.InputField
+	promptFor string // "filter", "search" or "export"
+	box       *tview.Flex
+}
+
+// gridPos is a cell in display coordinates (data row, column).
+type gridPos struct{ row, col int }
+
+func (s *uiState) buildResultPane() *tview.Flex {
+	g := &gridState{sortCol: -1, layout: &columnLayout{}}
+	s.grid = g
+
+	s.result = tview.NewTable().
+		SetBorders(true). // show grid
+		SetFixed(1, 0)
+	s.result.SetBorder(true)
+	s.result.SetTitle(" Results ")
+	s.result.SetSelectable(true, true) // move across cells
+	themeSelection(s.result)
+	s.result.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if s.lastRows == nil {
+			return ev
+		}
+		if id := s.keys.action(ev, "results"); id != "" {
+			s.runAction(id)
+			return nil
+		}
+		if ev.Key() == tcell.KeyEnter {
+			return nil // expand was rebound
+		}
+		return ev
+	})
+
+	g.prompt = tview.NewInputField().
+		SetFieldWidth(0)
+	g.prompt.SetChangedFunc(func(text string) {
+		if g.promptFor == "search" {
+			s.setSearch(text)
+		}
+	})
+	g.prompt.SetDoneFunc(func(key tcell.Key) {
+		switch {
+		case key == tcell.KeyEsc:
+			// Esc drops what the prompt controls.
+			switch g.promptFor {
+			case "filter":
+				s.setFilter("")
+			case "search":
+				s.setSearch("")
+			}
+		case key == tcell.KeyEnter && g.promptFor == "filter":
+			if err := s.setFilter(g.prompt.GetText()); err != nil {
+				s.setStatus(fmt.Sprintf(redTag+"Filter error:[-] %v", err))
+				return // keep the prompt open to fix it
+			}
+		case key == tcell.KeyEnter && g.promptFor == "export":
+			if err := s.exportGrid(g.prompt.GetText()); err != nil {
+				s.setStatus(fmt.Sprintf(redTag+"Export failed:[-] %v", err))
+				return
+			}
+		}
+		s.closeGridPrompt()
+	})
+
+	g.box = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(s.result, 0, 1, false).
+		AddItem(g.prompt, 0, 0, false)
+	return g.box
+}
+
+func (s *uiState) openGridPrompt(kind, text string) {
+	g := s.grid
+	g.promptFor = kind
+	g.prompt.SetLabel(kind + ": ")
+	g.prompt.SetText(text)
+	g.box.ResizeItem(g.prompt, 1, 0)
+	s.app.SetFocus(g.prompt)
+}
+
+func (s *uiState) closeGridPrompt() {
+	g := s.grid
+	g.box.ResizeItem(g.prompt, 0, 0)
+	if s.app.GetFocus() == g.prompt {
+		s.app.SetFocus(s.result)
+	}
+}
+
+// resetGrid forgets sort, filter and search and loads the column layout
+// of table ("" if unknown); used for each new result.
+func (s *uiState) resetGrid(table string) {
+	g := s.grid
+	g.sortCol, g.sortDesc = -1, false
+	g.filter, g.keep = "", nil
+	g.search, g.matches = "", nil
+	g.table = table
+	s.loadLayout()
+	s.closeGridPrompt()
+}
+
+// rowAt maps a display row (0-based, header excluded) to the data row.
+func (s *uiState) rowAt(displayRow int) (db.Row, bool) {
+	if s.lastRows == nil || displayRow < 0 || displayRow >= len(s.grid.view) {
+		return nil, false
+	}
+	return s.lastRows.Data[s.grid.view[displayRow]], true
+}
+
+// applyView recomputes the visible rows from filter and sort and redraws.
+func (s *uiState) applyView() {
+	g := s.grid
+	rows := s.lastRows
+
+	g.view = g.view[:0]
+	for i, row := range rows.Data {
+		if g.keep == nil || g.keep(row) {
+			g.view = append(g.view, i)
+		}
+	}
+	if g.sortCol >= 0 {
+		col := g.sortCol
+		cell := func(i int) any {
+			if row := rows.Data[i]; col < len(row) {
+				return row[col]
+			}
+			return nil
+		}
+		sort.SliceStable(g.view, func(i, j int) bool {
+			c := compareCells(cell(g.view[i]), cell(g.view[j]))
+			if g.sortDesc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	s.layoutColumns()
+	s.findMatches()
+	s.drawGrid()
+
+	title := " Results "
+	if g.keep != nil {
+		title = fmt.Sprintf(" Results (%d of %d) ", len(g.view), len(rows.Data))
+	}
+	s.result.SetTitle(title)
+}
+
+// viewSummary describes the visible rows for the status bar.
+func (s *uiState) viewSummary() string {
+	g := s.grid
+	var parts []string
+	if g.keep != nil {
+		parts = append(parts, fmt.Sprintf("showing %d of %d rows", len(g.view), len(s.lastRows.Data)))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d rows", len(g.view)))
+	}
+	if g.sortCol >= 0 && g.sortCol < len(s.lastRows.Columns) {
+		dir := "asc"
+		if g.sortDesc {
+			dir = "desc"
+		}
+		parts = append(parts, fmt.Sprintf("sorted by %s %s", s.lastRows.Columns[g.sortCol].Name, dir))
+	}
+	if g.search != "" {
+		parts = append(parts, fmt.Sprintf("%d matches for %q", len(g.matches), g.search))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cycleSort sorts by col ascending, then descending, then back to query order.
+func (s *uiState) cycleSort(col int) {
+	g := s.grid
+	if s.lastRows == nil || col < 0 || col >= len(s.lastRows.Columns) {
+		return
+	}
+	switch {
+	case g.sortCol != col:
+		g.sortCol, g.sortDesc = col, false
+	case !g.sortDesc:
+		g.sortDesc = true
+	default:
+		g.sortCol, g.sortDesc = -1, false
+	}
+	s.applyView()
+	s.selectCell(0, s.displayCol(col))
+	s.setStatus(greenTag + "Results[-] " + dimTag + "(" + tview.Escape(s.viewSummary()) + ")[-]")
+}
+
+func (s *uiState) setFilter(expr string) error {
+	g := s.grid
+	if s.lastRows == nil {
+		return nil
+	}
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		g.filter, g.keep = "", nil
+	} else {
+		keep, err := parseRowFilter(expr, s.lastRows.Columns, s.dialect)
+		if err != nil {
+			return err
+		}
+		g.filter, g.keep = expr, keep
+	}
+	s.applyView()
+	s.selectCell(0, 0)
+	s.setStatus(greenTag + "Results[-] " + dimTag + "(" + tview.Escape(s.viewSummary()) + ")[-]")
+	return nil
+}
+
+// setSearch highlights cells containing text and jumps to the first
+// match at or after the selection.
+func (s *uiState) setSearch(text string) {
+	if s.lastRows == nil {
+		return
+	}
+	s.grid.search = text
+	s.findMatches()
+	s.drawGrid()
+	s.nextMatch(0)
+}
+
+func (s *uiState) findMatches() {
+	g := s.grid
+	g.matches = nil
+	if g.search == "" {
+		return
+	}
+	needle := strings.ToLower(g.search)
+	for r, i := range g.view {
+		row := s.lastRows.Data[i]
+		for c, col := range g.cols {
+			if col < len(row) && strings.Contains(strings.ToLower(formatValue(row[col])), needle) {
+				g.matches = append(g.matches, gridPos{r, c})
+			}
+		}
+	}
+}
+
+// nextMatch moves the selection to the next (dir 1), previous (-1) or
+// current-or-next (0) search match, wrapping around.
+func (s *uiState) nextMatch(dir int) {
+	g := s.grid
+	if g.search == "" {
+		return
+	}
+	if len(g.matches) == 0 {
+		s.setStatus(fmt.Sprintf(yellowTag+"No matches for[-] %q", tview.Escape(g.search)))
+		return
+	}
+
+	row, col := s.selectedCell()
+	cur := gridPos{row, col}
+	before := func(a, b gridPos) bool {
+		return a.row < b.row || (a.row == b.row && a.col < b.col)
+	}
+
+	idx := -1
+	switch dir {
+	case 0, 1:
+		for i, m := range g.matches {
+			if (dir == 0 && !before(m, cur)) || (dir == 1 && before(cur, m)) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = 0
+		}
+	default:
+		for i := len(g.matches) - 1; i >= 0; i-- {
+			if before(g.matches[i], cur) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(g.matches) - 1
+		}
+	}
+
+	m := g.matches[idx]
+	s.selectCell(m.row, m.col)
+	s.setStatus(fmt.Sprintf(greenTag+"Match %d of %d[-] "+dimTag+"(n/N next/previous)[-]", idx+1, len(g.matches)))
+}
+
+// isSearchMatch reports whether the display cell is a search hit.
+func (g *gridState) isSearchMatch(row, col int) bool {
+	i := sort.Search(len(g.matches), func(i int) bool {
+		m := g.matches[i]
+		return m.row > row || (m.row == row && m.col >= col)
+	})
+	return i < len(g.matches) && g.matches[i] == gridPos{row, col}
+}
+
+// markSearchMatch highlights a matching cell; without colours, by underline.
+func markSearchMatch(cell *tview.TableCell) {
+	cell.SetBackgroundColor(searchMatchColor)
+	if monoTheme {
+		cell.SetAttributes(cell.Attributes | tcell.AttrUnderline)
+	}
+}
+
+// selectedCell returns the selection as display row (0-based, -1 for the
+// header) and display column, whichever way the grid is drawn.
+func (s *uiState) selectedCell() (row, col int) {
+	r, c := s.result.GetSelection()
+	if s.grid.transposed {
+		return c - 1, r - 1
+	}
+	return r - 1, c
+}
+
+// selectCell selects a display row and column (see selectedCell).
+func (s *uiState) selectCell(row, col int) {
+	if row < 0 {
+		row = 0
+	}
+	if col < 0 {
+		col = 0
+	}
+	if s.grid.transposed {
+		s.result.Select(col+1, row+1)
+		return
+	}
+	s.result.Select(row+1, col)
+}
+
+func (s *uiState) toggleTranspose() {
+	row, col := s.selectedCell()
+	s.grid.transposed = !s.grid.transposed
+	s.drawGrid()
+	s.selectCell(row, col)
+}
+
+// drawTransposed fills the results table with one line per visible
+// column and one column per record.
+func (s *uiState) drawTransposed() {
+	g := s.grid
+	rows := s.lastRows
+
+	nameWidth := 0
+	for _, c := range g.cols {
+		if l := runeLen(rows.Columns[c].Name) + 2; l > nameWidth {
+			nameWidth = l
+		}
+	}
+
+	s.result.SetCell(0, 0, tview.NewTableCell(padRight("column", nameWidth)).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold))
+	for rIdx := range g.view {
+		s.result.SetCell(0, rIdx+1, tview.NewTableCell(fmt.Sprintf("#%d", rIdx+1)).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold))
+	}
+
+	for cIdx, c := range g.cols {
+		name := rows.Columns[c].Name
+		if c == g.sortCol {
+			if g.sortDesc {
+				name += " ▼"
+			} else {
+				name += " ▲"
+			}
+		}
+		cell := tview.NewTableCell(padRight(name, nameWidth)).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold)
+		cell.SetClickedFunc(func() bool {
+			s.cycleSort(c)
+			return true
+		})
+		s.result.SetCell(cIdx+1, 0, cell)
+
+		limit, _ := g.columnWidthLimit(rows.Columns[c].Name)
+		for rIdx, dataIdx := range g.view {
+			var v any
+			if row := rows.Data[dataIdx]; c < len(row) {
+				v = row[c]
+			}
+			text, kind := cellfmt.Format(v)
+			align := tview.AlignLeft
+			if kind == cellfmt.Plain && looksNumeric(text) {
+				align = tview.AlignRight
+			}
+			cell := tview.NewTableCell(truncateCell(text, limit)).
+				SetAlign(align).
+				SetSelectable(true)
+			styleCell(cell, kind)
+			// zebra striping per line, as in the normal grid
+			if cIdx%2 == 1 {
+				cell.SetBackgroundColor(zebraColor)
+			}
+			if g.isSearchMatch(rIdx, cIdx) {
+				markSearchMatch(cell)
+			}
+			s.result.SetCell(cIdx+1, rIdx+1, cell)
+		}
+	}
+
+	s.result.SetFixed(1, 1)
+	s.result.ScrollToBeginning()
+}
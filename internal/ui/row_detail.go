@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
+)
+
+// rowDetailText renders one row as "name:\n  value" sections, limited to
+// columns whose name contains filter (case-insensitive).
+func (s *uiState) rowDetailText(displayRow int, filter string) string {
+	row, _ := s.rowAt(displayRow)
+	filter = strings.ToLower(filter)
+
+	var b strings.Builder
+	b.Grow(256)
+
+	for _, i := range s.orderedColumns() {
+		col := s.lastRows.Columns[i]
+		if filter != "" && !strings.Contains(strings.ToLower(col.Name), filter) {
+			continue
+		}
+		b.WriteString("[::b]")
+		b.WriteString(tview.Escape(col.Name))
+		b.WriteString(":[::-]")
+		if s.grid.layout.isHidden(col.Name) {
+			b.WriteString(" " + dimTag + "(hidden in grid)[-]")
+		}
+		b.WriteString("\n")
+
+		var v any
+		if i < len(row) {
+			v = row[i]
+		}
+		if isJSONColumn(col, v) {
+			raw, _ := jsonText(v)
+			for _, line := range strings.Split(prettyJSON(raw), "\n") {
+				b.WriteString("  ")
+				b.WriteString(line)
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+			continue
+		}
+
+		if isPGArrayColumn(col, v) {
+			arr, _ := parsePGArray(v.(string))
+			var lines []string
+			formatPGArrayLines(arr, "", &lines)
+			for _, line := range lines {
+				b.WriteString("  ")
+				b.WriteString(tview.Escape(line))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+			continue
+		}
+
+		b.WriteString("  ")
+		switch text, kind := cellfmt.Format(v); kind {
+		case cellfmt.Null:
+			b.WriteString(dimTag + "[::i]" + tview.Escape(text) + "[-::-]")
+		case cellfmt.Empty:
+			b.WriteString(dimTag + "[::i](empty string)[-::-]")
+		case cellfmt.Blank:
+			b.WriteString(dimTag + tview.Escape(text) + "[-] " + dimTag + "[::i](whitespace only)[-::-]")
+		case cellfmt.Binary:
+			b.WriteString(dimTag + tview.Escape(text) + "[-]")
+		default:
+			// Full text, line breaks kept; other control characters shown.
+			val := cellfmt.Visible(formatValue(v), true)
+			b.WriteString(tview.Escape(strings.ReplaceAll(val, "\n", "\n  ")))
+		}
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// showRowDetail opens the record navigator on a display row: n/p step
+// through the (filtered, sorted) rows, / narrows the columns by name.
+func (s *uiState) showRowDetail(displayRow int) {
+	total := len(s.grid.view)
+
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true).
+		SetWordWrap(true)
+
+	header := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+
+	filter := tview.NewInputField().
+		SetLabel("column: ").
+		SetFieldWidth(0)
+
+	render := func() {
+		header.SetText(fmt.Sprintf("Row [::b]%d[::-] of %d  %s  %s  %s  %s",
+			displayRow+1, total,
+			s.keys.hint("row.next", "next row"),
+			s.keys.hint("row.prev", "previous row"),
+			s.keys.hint("row.find-column", "find column"),
+			s.keys.hint("overlay.close", "close")))
+		text.SetText(s.rowDetailText(displayRow, filter.GetText()))
+	}
+	render()
+
+	filter.SetChangedFunc(func(string) {
+		render()
+		text.ScrollToBeginning()
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(header, 3, 0, false).
+		AddItem(text, 0, 1, true)
+
+	filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			filter.SetText("")
+			layout.RemoveItem(filter)
+		}
+		s.app.SetFocus(text)
+	})
+
+	text.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch id := s.keys.action(ev, "row"); id {
+		case "row.next", "row.prev":
+			next := displayRow + 1
+			if id == "row.prev" {
+				next = displayRow - 1
+			}
+			if next < 0 || next >= total {
+				return nil
+			}
+			displayRow = next
+			render()
+			text.ScrollToBeginning()
+			// Keep the grid selection in step, so closing lands on this row.
+			_, col := s.selectedCell()
+			s.selectCell(displayRow, col)
+			return nil
+		case "row.find-column":
+			if layout.GetItemCount() == 2 {
+				layout.AddItem(filter, 1, 0, false)
+			}
+			s.app.SetFocus(filter)
+			return nil
+		}
+		return ev
+	})
+
+	frame := tview.NewFrame(layout).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(" Row detail ").
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("rowDetail", centered(frame), true)
+	s.app.SetFocus(text)
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/schemacache"
+)
+
+// schemaModel answers catalog questions for completion and the
+// structure view from the schema cache.
+type schemaModel struct {
+	cache *schemacache.Cache
+}
+
+func (m *schemaModel) tables() []string {
+	return m.cache.Tables()
+}
+
+// schemas returns the distinct schema prefixes of "schema.table" names.
+func (m *schemaModel) schemas() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range m.tables() {
+		if dot := strings.Index(t, "."); dot > 0 {
+			sch := t[:dot]
+			if !seen[sch] {
+				seen[sch] = true
+				out = append(out, sch)
+			}
+		}
+	}
+	return out
+}
+
+// resolveTable maps a reference as typed ("users", "public.users",
+// `"Users"`) to a name from the table list.
+func (m *schemaModel) resolveTable(ref string) (string, bool) {
+	ref = unquoteIdent(ref)
+	tables := m.tables()
+	for _, t := range tables {
+		if strings.EqualFold(t, ref) {
+			return t, true
+		}
+	}
+	for _, t := range tables {
+		if dot := strings.LastIndex(t, "."); dot >= 0 && strings.EqualFold(t[dot+1:], ref) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// tableColumns returns the columns of table, describing it on a cache miss.
+func (m *schemaModel) tableColumns(ctx context.Context, table string) []db.Column {
+	cols, err := m.cache.DescribeTable(ctx, table)
+	if err != nil {
+		return nil
+	}
+	return cols
+}
+
+// unquoteIdent strips one level of double-quote, backtick or bracket quoting from each part
+// of a dotted identifier.
+func unquoteIdent(s string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		if len(p) >= 2 {
+			switch {
+			case p[0] == '"' && p[len(p)-1] == '"',
+				p[0] == '`' && p[len(p)-1] == '`',
+				p[0] == '[' && p[len(p)-1] == ']':
+				parts[i] = p[1 : len(p)-1]
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
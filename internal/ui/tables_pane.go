@@ -0,0 +1,386 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// tableEntry is one line of the tables pane: a schema header, or a table.
+type tableEntry struct {
+	header bool
+	schema string // group name; "" for unqualified names
+	table  string // full name as listed by the driver
+	pinned bool   // in the pinned group (or its header)
+}
+
+func (e tableEntry) key() string {
+	switch {
+	case e.header && e.pinned:
+		return "pinned"
+	case e.header:
+		return "h:" + e.schema
+	case e.pinned:
+		return "p:" + e.table
+	}
+	return "t:" + e.table
+}
+
+// tablesPane is the state behind the tables list: grouping, filter and
+// the entries currently shown (parallel to the list items).
+type tablesPane struct {
+	all       []string
+	entries   []tableEntry
+	collapsed map[string]bool
+	filter    *tview.InputField
+	left      *tview.Flex
+}
+
+// fuzzyMatch matches pattern as a case-insensitive subsequence of s and
+// returns the matched rune positions. The score favours consecutive
+// runs and matches at the start of a word ("public.user_roles": p, u, r).
+func fuzzyMatch(pattern, s string) (int, []int, bool) {
+	pat := []rune(strings.ToLower(pattern))
+	if len(pat) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(s)
+	pos := make([]int, 0, len(pat))
+	score := 0
+	pi := 0
+	for i, r := range runes {
+		if pi == len(pat) {
+			break
+		}
+		if unicode.ToLower(r) != pat[pi] {
+			continue
+		}
+		score++
+		if len(pos) > 0 && pos[len(pos)-1] == i-1 {
+			score += 3
+		}
+		if i == 0 || strings.ContainsRune("._ -", runes[i-1]) {
+			score += 2
+		}
+		pos = append(pos, i)
+		pi++
+	}
+	if pi < len(pat) {
+		return 0, nil, false
+	}
+	return score - len(runes)/8, pos, true
+}
+
+// highlightMatches renders runes of s from offset on, coloring the
+// matched positions (which index the whole string).
+func highlightMatches(s string, offset int, pos []int) string {
+	matched := map[int]bool{}
+	for _, p := range pos {
+		matched[p] = true
+	}
+	var b, run strings.Builder
+	flush := func() {
+		b.WriteString(tview.Escape(run.String()))
+		run.Reset()
+	}
+	for i, r := range []rune(s) {
+		if i < offset {
+			continue
+		}
+		if matched[i] {
+			flush()
+			b.WriteString("[" + fuzzyMatchColor + "::b]" + tview.Escape(string(r)) + "[-::-]")
+			continue
+		}
+		run.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+func splitSchema(table string) (string, string) {
+	if dot := strings.Index(table, "."); dot > 0 {
+		return table[:dot], table[dot+1:]
+	}
+	return "", table
+}
+
+func (s *uiState) buildTablesPane() *tview.Flex {
+	p := &tablesPane{collapsed: map[string]bool{}}
+	s.tablePane = p
+
+	s.tables = tview.NewList().
+		ShowSecondaryText(false)
+	s.tables.SetBorder(true)
+	s.tables.SetTitle(" Tables ")
+	s.tables.SetDoneFunc(func() {
+		// ESC in list -> focus query.
+		s.app.SetFocus(s.query)
+	})
+	themeSelection(s.tables)
+	s.tables.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if id := s.keys.action(ev, "tables"); id != "" {
+			s.runAction(id)
+			return nil
+		}
+		if ev.Key() == tcell.KeyEnter {
+			return nil // open was rebound
+		}
+		return ev
+	})
+	s.tables.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
+		s.openTableEntry(index) // mouse click
+	})
+
+	p.filter = tview.NewInputField().
+		SetLabel("/").
+		SetFieldWidth(0)
+	p.filter.SetChangedFunc(func(string) { s.renderTables(true) })
+	p.filter.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch ev.Key() {
+		case tcell.KeyEsc:
+			s.closeTableFilter()
+			return nil
+		case tcell.KeyEnter, tcell.KeyDown, tcell.KeyTab:
+			s.app.SetFocus(s.tables)
+			return nil
+		}
+		return ev
+	})
+
+	p.left = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(s.tables, 0, 1, true).
+		AddItem(p.filter, 0, 0, false)
+	return p.left
+}
+
+// openTableEntry runs the default query on a table, or folds a schema.
+func (s *uiState) openTableEntry(index int) {
+	p := s.tablePane
+	if index < 0 || index >= len(p.entries) {
+		return
+	}
+	e := p.entries[index]
+	if e.header && e.pinned {
+		return
+	}
+	if e.header {
+		p.collapsed[e.schema] = !p.collapsed[e.schema]
+		s.renderTables(false)
+		return
+	}
+	sql := fmt.Sprintf("SELECT * FROM %s LIMIT 100", e.table)
+	if s.dialect == sqllex.MSSQL {
+		sql = fmt.Sprintf("SELECT TOP 100 * FROM %s", e.table)
+	}
+	s.query.SetText(sql, true)
+	s.runQuery(sql) // synchronous
+}
+
+func (s *uiState) openTableFilter() {
+	s.tablePane.left.ResizeItem(s.tablePane.filter, 1, 0)
+	s.app.SetFocus(s.tablePane.filter)
+}
+
+func (s *uiState) closeTableFilter() {
+	p := s.tablePane
+	p.filter.SetText("") // re-renders via the changed func
+	p.left.ResizeItem(p.filter, 0, 0)
+	s.app.SetFocus(s.tables)
+}
+
+// fillTables sets the full table list and redraws the pane.
+func (s *uiState) fillTables(tables []string) {
+	var all []string
+	for _, t := range tables {
+		if name := strings.TrimSpace(t); name != "" {
+			all = append(all, name)
+		}
+	}
+	s.tablePane.all = all
+	s.renderTables(false)
+}
+
+// selectedTable returns the table under the cursor, if it is not a header.
+func (s *uiState) selectedTable() (string, bool) {
+	idx := s.tables.GetCurrentItem()
+	if idx < 0 || idx >= len(s.tablePane.entries) || s.tablePane.entries[idx].header {
+		return "", false
+	}
+	return s.tablePane.entries[idx].table, true
+}
+
+func (s *uiState) togglePinSelected() {
+	table, ok := s.selectedTable()
+	if !ok {
+		return
+	}
+	pinned := s.prefs.togglePin(table)
+	if err := s.prefs.save(s.prefsPath); err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Could not save pins:[-] %v", err))
+	} else if pinned {
+		s.setStatus(fmt.Sprintf(greenTag+"Pinned[-] %s", tview.Escape(table)))
+	} else {
+		s.setStatus(fmt.Sprintf(dimTag+"Unpinned %s[-]", tview.Escape(table)))
+	}
+	s.renderTables(false)
+}
+
+// renderTables rebuilds the list from the table names, pins, filter and
+// collapsed groups. With jumpToMatch the cursor moves to the best match,
+// otherwise it stays on the same entry when that is still shown.
+func (s *uiState) renderTables(jumpToMatch bool) {
+	p := s.tablePane
+
+	current := ""
+	if idx := s.tables.GetCurrentItem(); idx >= 0 && idx < len(p.entries) {
+		current = p.entries[idx].key()
+	}
+
+	type match struct {
+		table string
+		score int
+		pos   []int
+	}
+	pattern := strings.TrimSpace(p.filter.GetText())
+	filtering := pattern != ""
+
+	var matches []match
+	for _, t := range p.all {
+		if score, pos, ok := fuzzyMatch(pattern, t); ok {
+			matches = append(matches, match{t, score, pos})
+		}
+	}
+	if filtering {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+
+	var entries []tableEntry
+	var labels []string
+	add := func(e tableEntry, label string) {
+		entries = append(entries, e)
+		labels = append(labels, label)
+	}
+
+	// Pinned tables first, in pin order (or match order when filtering).
+	var pinned []match
+	for _, m := range matches {
+		if s.prefs.isPinned(m.table) {
+			pinned = append(pinned, m)
+		}
+	}
+	if !filtering {
+		sort.SliceStable(pinned, func(i, j int) bool {
+			return pinIndex(s.prefs.Pinned, pinned[i].table) < pinIndex(s.prefs.Pinned, pinned[j].table)
+		})
+	}
+	if len(pinned) > 0 {
+		add(tableEntry{header: true, pinned: true}, fmt.Sprintf("[%s]★ Pinned[-] "+dimTag+"(%d)[-]", pinColor, len(pinned)))
+		for _, m := range pinned {
+			add(tableEntry{table: m.table, pinned: true}, " ["+pinColor+"]★[-] "+highlightMatches(m.table, 0, m.pos))
+		}
+	}
+
+	// Then everything, grouped by schema when names are qualified.
+	grouped := false
+	for _, t := range p.all {
+		if sch, _ := splitSchema(t); sch != "" {
+			grouped = true
+			break
+		}
+	}
+	if !grouped {
+		for _, m := range matches {
+			add(tableEntry{table: m.table}, highlightMatches(m.table, 0, m.pos))
+		}
+	} else {
+		var order []string
+		groups := map[string][]match{}
+		for _, m := range matches {
+			sch, _ := splitSchema(m.table)
+			if _, ok := groups[sch]; !ok {
+				order = append(order, sch)
+			}
+			groups[sch] = append(groups[sch], m)
+		}
+		for _, sch := range order {
+			ms := groups[sch]
+			// While filtering every group is open, or matches would hide.
+			collapsed := p.collapsed[sch] && !filtering
+			arrow := "▾"
+			if collapsed {
+				arrow = "▸"
+			}
+			name := sch
+			if name == "" {
+				name = "(no schema)"
+			}
+			add(tableEntry{header: true, schema: sch},
+				fmt.Sprintf("%s [::b]%s[::-] "+dimTag+"(%d)[-]", arrow, tview.Escape(name), len(ms)))
+			if collapsed {
+				continue
+			}
+			for _, m := range ms {
+				offset := 0
+				if sch != "" {
+					offset = len([]rune(sch)) + 1
+				}
+				add(tableEntry{table: m.table, schema: sch}, "  "+highlightMatches(m.table, offset, m.pos))
+			}
+		}
+	}
+
+	p.entries = entries
+	s.tables.Clear()
+	for _, l := range labels {
+		s.tables.AddItem(l, "", 0, nil)
+	}
+
+	title := " Tables "
+	if filtering {
+		title = fmt.Sprintf(" Tables (%d of %d) ", len(matches), len(p.all))
+	}
+	s.tables.SetTitle(title)
+
+	if len(entries) == 0 {
+		return
+	}
+	// Matches are sorted best first, so the first table is the best one.
+	sel := -1
+	if !(jumpToMatch && filtering) {
+		for i, e := range entries {
+			if e.key() == current {
+				sel = i
+				break
+			}
+		}
+	}
+	if sel < 0 {
+		sel = 0
+		for i, e := range entries {
+			if !e.header {
+				sel = i
+				break
+			}
+		}
+	}
+	s.tables.SetCurrentItem(sel)
+}
+
+func pinIndex(pins []string, table string) int {
+	for i, t := range pins {
+		if t == table {
+			return i
+		}
+	}
+	return len(pins)
+}
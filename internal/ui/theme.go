@@ -0,0 +1,403 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bgunnarsson/binsql/internal/sqllex"
+)
+
+// Theme is a colour scheme. Colours are "#RRGGBB" or a name tcell knows
+// ("navy", "silver", "default", …). Fields left empty are taken from the
+// theme named by Base, or from "dark".
+type Theme struct {
+	Name string `json:"name,omitempty"`
+	Base string `json:"base,omitempty"`
+
+	// Mono marks a theme for terminals without colour (NO_COLOR): the
+	// selection is drawn in reverse video instead of by colour.
+	Mono bool `json:"mono,omitempty"`
+
+	Background string `json:"background,omitempty"` // panes
+	Surface    string `json:"surface,omitempty"`    // input fields
+	Overlay    string `json:"overlay,omitempty"`    // more contrast
+	Zebra      string `json:"zebra,omitempty"`      // every other results row
+	Match      string `json:"match,omitempty"`      // search hits in the results
+	Border     string `json:"border,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Subtext    string `json:"subtext,omitempty"`
+	Muted      string `json:"muted,omitempty"` // NULLs, empty strings
+	Title      string `json:"title,omitempty"`
+	Accent     string `json:"accent,omitempty"` // driver name in the header
+
+	Red    string `json:"red,omitempty"`
+	Orange string `json:"orange,omitempty"`
+	Yellow string `json:"yellow,omitempty"`
+	Green  string `json:"green,omitempty"`
+	Blue   string `json:"blue,omitempty"`
+	Purple string `json:"purple,omitempty"`
+	Cyan   string `json:"cyan,omitempty"`
+}
+
+// colors lists the colour fields by JSON name.
+func (t *Theme) colors() map[string]*string {
+	return map[string]*string{
+		"background": &t.Background,
+		"surface":    &t.Surface,
+		"overlay":    &t.Overlay,
+		"zebra":      &t.Zebra,
+		"match":      &t.Match,
+		"border":     &t.Border,
+		"text":       &t.Text,
+		"subtext":    &t.Subtext,
+		"muted":      &t.Muted,
+		"title":      &t.Title,
+		"accent":     &t.Accent,
+		"red":        &t.Red,
+		"orange":     &t.Orange,
+		"yellow":     &t.Yellow,
+		"green":      &t.Green,
+		"blue":       &t.Blue,
+		"purple":     &t.Purple,
+		"cyan":       &t.Cyan,
+	}
+}
+
+// bundledThemes are always available by name.
+var bundledThemes = map[string]Theme{
+	// Catppuccin Mocha.
+	"dark": {
+		Background: "#1E1E2E", // base
+		Surface:    "#313244", // surface0
+		Overlay:    "#45475A", // surface1
+		Zebra:      "#181825", // mantle
+		Match:      "#585B70", // surface2
+		Border:     "#595B72",
+		Text:       "#CDD6F4",
+		Subtext:    "#A6ADC8", // subtext0
+		Muted:      "#9399B2", // overlay2
+		Title:      "#89DCEB", // sky
+		Accent:     "#C0A1F0",
+		Red:        "#F38BA8",
+		Orange:     "#FAB387", // peach
+		Yellow:     "#F9E2AF",
+		Green:      "#A6E3A1",
+		Blue:       "#89B4FA",
+		Purple:     "#CBA6F7", // mauve
+		Cyan:       "#89DCEB",
+	},
+	// Catppuccin Latte.
+	"light": {
+		Background: "#EFF1F5",
+		Surface:    "#CCD0DA",
+		Overlay:    "#BCC0CC",
+		Zebra:      "#E6E9EF",
+		Match:      "#ACB0BE",
+		Border:     "#9CA0B0",
+		Text:       "#4C4F69",
+		Subtext:    "#6C6F85",
+		Muted:      "#7C7F93",
+		Title:      "#04A5E5",
+		Accent:     "#8839EF",
+		Red:        "#D20F39",
+		Orange:     "#FE640B",
+		Yellow:     "#DF8E1D",
+		Green:      "#40A02B",
+		Blue:       "#1E66F5",
+		Purple:     "#8839EF",
+		Cyan:       "#179299",
+	},
+	// The 16 ANSI colours, for terminals without 256-colour support; the
+	// terminal's own palette decides what they look like.
+	"ansi16": {
+		Background: "black",
+		Surface:    "navy",
+		Overlay:    "teal",
+		Zebra:      "black",
+		Match:      "olive",
+		Border:     "gray",
+		Text:       "white",
+		Subtext:    "silver",
+		Muted:      "gray",
+		Title:      "aqua",
+		Accent:     "fuchsia",
+		Red:        "red",
+		Orange:     "olive",
+		Yellow:     "yellow",
+		Green:      "lime",
+		Blue:       "blue",
+		Purple:     "purple",
+		Cyan:       "aqua",
+	},
+	// No colours at all; picked when NO_COLOR is set.
+	"mono": {
+		Mono:       true,
+		Background: "default",
+		Surface:    "default",
+		Overlay:    "default",
+		Zebra:      "default",
+		Match:      "default",
+		Border:     "default",
+		Text:       "default",
+		Subtext:    "default",
+		Muted:      "default",
+		Title:      "default",
+		Accent:     "default",
+		Red:        "default",
+		Orange:     "default",
+		Yellow:     "default",
+		Green:      "default",
+		Blue:       "default",
+		Purple:     "default",
+		Cyan:       "default",
+	},
+}
+
+// Colours from the active theme that tview.Styles has no slot for. The
+// string ones are for color tags: "[" + pinColor + "]★[-]".
+var (
+	monoTheme        bool
+	zebraColor       = tcell.NewRGBColor(24, 24, 37)
+	searchMatchColor = tcell.NewRGBColor(88, 91, 112)
+
+	accentColor     = "#C0A1F0"
+	fuzzyMatchColor = "#F9E2AF"
+	pinColor        = "#F9E2AF"
+
+	jsonKeyColor     = "#89B4FA"
+	jsonStringColor  = "#A6E3A1"
+	jsonNumberColor  = "#FAB387"
+	jsonLiteralColor = "#CBA6F7"
+
+	planScanColor   = "#F38BA8" // full scans
+	planCostColor   = "#FAB387" // the most expensive node
+	planTargetColor = "#89B4FA"
+	planDetailColor = "#A6ADC8"
+
+	notifyChannelColor = "#89DCEB"
+)
+
+// Color tags for status messages and hints, from the theme's red,
+// yellow, green and muted colours: redTag + "Query error:[-] …".
+var (
+	redTag    = "[#F38BA8]"
+	yellowTag = "[#F9E2AF]"
+	greenTag  = "[#A6E3A1]"
+	dimTag    = "[#9399B2]"
+)
+
+// loadTheme resolves name: a bundled theme, or <name>.json in dir. An
+// empty name picks a theme for the terminal (see defaultThemeName).
+// NO_COLOR always wins, since tcell drops every colour then anyway.
+func loadTheme(name, dir string) (*Theme, error) {
+	if name == "" || os.Getenv("NO_COLOR") != "" {
+		name = defaultThemeName()
+	}
+	return resolveTheme(name, dir, 0)
+}
+
+func resolveTheme(name, dir string, depth int) (*Theme, error) {
+	if depth > 8 {
+		return nil, fmt.Errorf("theme %q: base themes nest too deep", name)
+	}
+	if t, ok := bundledThemes[name]; ok {
+		t.Name = name
+		return &t, nil
+	}
+	if dir == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("unknown theme %q", name)
+	}
+	path := filepath.Join(dir, name+".json")
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unknown theme %q (no bundled theme or %s)", name, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("theme %q: %w", name, err)
+	}
+	t := &Theme{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.Name = name
+	for field, v := range t.colors() {
+		if *v != "" && !validColor(*v) {
+			return nil, fmt.Errorf("%s: %s: unknown colour %q", path, field, *v)
+		}
+	}
+
+	baseName := t.Base
+	if baseName == "" {
+		baseName = "dark"
+	}
+	base, err := resolveTheme(baseName, dir, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	baseColors := base.colors()
+	for field, v := range t.colors() {
+		if *v == "" {
+			*v = *baseColors[field]
+		}
+	}
+	t.Mono = t.Mono || base.Mono
+	return t, nil
+}
+
+func validColor(v string) bool {
+	return strings.EqualFold(v, "default") || tcell.GetColor(v) != tcell.ColorDefault
+}
+
+// defaultThemeName follows the environment: mono under NO_COLOR, ansi16
+// on terminals that don't advertise 256 colours or truecolor, else dark.
+func defaultThemeName() string {
+	if os.Getenv("NO_COLOR") != "" {
+		return "mono"
+	}
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return "dark"
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return "dark"
+	}
+	return "ansi16"
+}
+
+// applyTheme installs t in tview.Styles and the package colour vars. It
+// must run before any widget is built.
+func applyTheme(t *Theme) {
+	c := tcell.GetColor
+
+	tview.Styles.PrimitiveBackgroundColor = c(t.Background)
+	tview.Styles.ContrastBackgroundColor = c(t.Surface)
+	tview.Styles.MoreContrastBackgroundColor = c(t.Overlay)
+	tview.Styles.BorderColor = c(t.Border)
+	tview.Styles.GraphicsColor = c(t.Border)
+	tview.Styles.PrimaryTextColor = c(t.Text)
+	tview.Styles.SecondaryTextColor = c(t.Subtext)
+	tview.Styles.TertiaryTextColor = c(t.Muted)
+	tview.Styles.TitleColor = c(t.Title)
+	tview.Styles.InverseTextColor = c(t.Blue)
+	tview.Styles.ContrastSecondaryTextColor = c(t.Subtext)
+
+	monoTheme = t.Mono
+	zebraColor = c(t.Zebra)
+	searchMatchColor = c(t.Match)
+
+	accentColor = t.Accent
+	fuzzyMatchColor = t.Yellow
+	pinColor = t.Yellow
+
+	jsonKeyColor = t.Blue
+	jsonStringColor = t.Green
+	jsonNumberColor = t.Orange
+	jsonLiteralColor = t.Purple
+
+	planScanColor = t.Red
+	planCostColor = t.Orange
+	planTargetColor = t.Blue
+	planDetailColor = t.Subtext
+
+	notifyChannelColor = t.Cyan
+
+	redTag = "[" + t.Red + "]"
+	yellowTag = "[" + t.Yellow + "]"
+	greenTag = "[" + t.Green + "]"
+	dimTag = "[" + t.Muted + "]"
+
+	sqllex.Colors = syntaxColors(t)
+}
+
+// syntaxColors maps SQL token kinds to the theme's colours. A mono
+// theme leaves SQL uncoloured.
+func syntaxColors(t *Theme) map[sqllex.Kind]string {
+	if t.Mono {
+		return map[sqllex.Kind]string{}
+	}
+	return map[sqllex.Kind]string{
+		sqllex.Keyword:     t.Purple,
+		sqllex.Function:    t.Blue,
+		sqllex.String:      t.Green,
+		sqllex.Number:      t.Orange,
+		sqllex.Comment:     t.Muted,
+		sqllex.Placeholder: t.Cyan,
+		sqllex.QuotedIdent: t.Yellow,
+		sqllex.Operator:    t.Cyan,
+	}
+}
+
+// EchoColors returns SGR parameters for sqllex.ANSIColors that highlight
+// SQL in the colours of the named theme (see loadTheme), so --echo
+// matches the TUI. It returns nil if the theme does not load.
+func EchoColors(name, dir string) map[sqllex.Kind]string {
+	t, err := loadTheme(name, dir)
+	if err != nil {
+		return nil
+	}
+	out := map[sqllex.Kind]string{}
+	for kind, color := range syntaxColors(t) {
+		sgr := colorSGR(tcell.GetColor(color))
+		if sgr == "" {
+			continue
+		}
+		if kind == sqllex.Keyword {
+			sgr = "1;" + sgr
+		}
+		out[kind] = sgr
+	}
+	return out
+}
+
+// colorSGR is the foreground SGR parameter for c: the 16 ANSI colours
+// by their own codes (so the terminal's palette applies, as in the
+// ansi16 theme), other palette colours as 256-colour and RGB ones as
+// truecolor. The default colour gives "".
+func colorSGR(c tcell.Color) string {
+	switch {
+	case c == tcell.ColorDefault || !c.Valid():
+		return ""
+	case c.IsRGB():
+		r, g, b := c.RGB()
+		return fmt.Sprintf("38;2;%d;%d;%d", r, g, b)
+	}
+	i := int(c - tcell.ColorValid)
+	switch {
+	case i < 8:
+		return strconv.Itoa(30 + i)
+	case i < 16:
+		return strconv.Itoa(90 + i - 8)
+	}
+	return "38;5;" + strconv.Itoa(i)
+}
+
+// themeSelection makes the cursor of a list, table or tree visible under
+// a mono theme, where tview's default colour swap shows nothing.
+func themeSelection(p tview.Primitive) {
+	if !monoTheme {
+		return
+	}
+	reverse := tcell.StyleDefault.Reverse(true)
+	switch w := p.(type) {
+	case *tview.List:
+		w.SetSelectedStyle(reverse)
+	case *tview.Table:
+		w.SetSelectedStyle(reverse)
+	case *tview.TreeView:
+		if root := w.GetRoot(); root != nil {
+			root.Walk(func(node, _ *tview.TreeNode) bool {
+				node.SetSelectedTextStyle(reverse)
+				return true
+			})
+		}
+	}
+}
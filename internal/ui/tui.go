@@ -11,39 +11,151 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/bgunnarsson/binsql/internal/blob"
+	"github.com/bgunnarsson/binsql/internal/cellfmt"
 	"github.com/bgunnarsson/binsql/internal/db"
+	"github.com/bgunnarsson/binsql/internal/db/health"
+	"github.com/bgunnarsson/binsql/internal/db/schemacache"
+	"github.com/bgunnarsson/binsql/internal/sqllex"
 )
 
 type uiState struct {
-	ctx    context.Context
-	db     db.DB
-	label  string
-	app    *tview.Application
-	pages  *tview.Pages
-	tables *tview.List
+	ctx     context.Context
+	db      db.DB
+	label   string
+	target  string
+	tls     string // negotiated TLS, e.g. "TLS 1.3 TLS_AES_128_GCM_SHA256"
+	tlsWarn bool   // the server certificate is not verified
+	dialect sqllex.Dialect
+	app     *tview.Application
+	screen  tcell.Screen
+	pages   *tview.Pages
+	header  *tview.TextView
+	tables  *tview.List
 
 	result   *tview.Table
-	query    *tview.InputField
+	query    *tview.TextArea
 	status   *tview.TextView
 	lastRows *db.Rows
+
+	notify     *notifyState       // LISTEN/NOTIFY page, built on first use
+	health     *health.DB         // connection state; nil if not watched
+	lastState  health.State       // for reporting state changes
+	cache      *schemacache.Cache // catalog, shared with the app layer
+	schema     *schemaModel       // catalog queries over cache
+	completion *completionState   // open completion drop-down
+	tablePane  *tablesPane        // grouping and filter of the tables list
+	prefs      *prefs             // per-connection pins etc.
+	prefsPath  string             // where prefs are saved ("" = nowhere)
+	grid       *gridState         // sort/filter/search over lastRows
+	keys       *keymap            // active key bindings
+	readOnly   bool               // refuse anything but a single SELECT
+
+	notice      string             // Options.Notice until the first schema load
+	connections []string           // saved connections to offer
+	open        func(c Connection) // see Options.Open
+
+	paletteFrom tview.Primitive // focus to restore when the palette closes
+}
+
+// Options carries per-connection settings from the app layer.
+type Options struct {
+	// PrefsPath is where UI preferences (pinned tables, …) for this
+	// connection are kept; empty disables persistence.
+	PrefsPath string
+
+	// Theme is a bundled theme name or a file in ThemeDir; empty picks
+	// one for the terminal.
+	Theme    string
+	ThemeDir string
+
+	// Keys rebinds actions (action id -> keys), see keymap.go.
+	Keys map[string][]string
+
+	// Target names the database in the header, e.g. "app@db:5432/shop".
+	// It never holds a password.
+	Target string
+
+	// TLS describes how the connection is encrypted, e.g. "TLS 1.3
+	// TLS_AES_128_GCM_SHA256" or "no TLS"; empty when unknown.
+	// Insecure flags a certificate that is not verified.
+	TLS      string
+	Insecure bool
+
+	// Health, if set, is pinged in the background; its state and latency
+	// are shown in the header.
+	Health *health.DB
+
+	// Notice is shown in the status bar at startup, e.g. why another
+	// connection could not be opened.
+	Notice string
+
+	// Connections are the saved connections the "open connection"
+	// action offers besides a typed driver and DSN. Picking one calls
+	// Open and quits Run, so the app layer can connect (prompting for
+	// passwords on the terminal) and run the TUI again.
+	Connections []string
+	Open        func(c Connection)
 }
 
-// Run starts the interactive TUI using tview/tcell.
-func Run(ctx context.Context, sdb db.DB, label string) error {
+// Connection is what the "open connection" action asks the app layer to
+// open: a saved connection by Name, or a DSN with its Driver (empty:
+// detect).
+type Connection struct {
+	Name   string
+	Driver string
+	DSN    string
+}
+
+// Run starts the interactive TUI using tview/tcell. If sdb is not
+// already behind a schema cache, an in-memory one is added.
+func Run(ctx context.Context, sdb db.DB, label string, opts Options) error {
+	cache, ok := sdb.(*schemacache.Cache)
+	if !ok {
+		cache = schemacache.New(sdb, schemacache.Options{})
+		sdb = cache
+	}
+
+	theme, err := loadTheme(opts.Theme, opts.ThemeDir)
+	if err != nil {
+		return err
+	}
+	applyTheme(theme)
+
 	state := &uiState{
-		ctx:   ctx,
-		db:    sdb,
-		label: label, // driver name, e.g. "sqlite"
-		app:   tview.NewApplication(),
+		ctx:       ctx,
+		db:        sdb,
+		label:     label, // driver name, e.g. "sqlite"
+		target:    opts.Target,
+		tls:       opts.TLS,
+		tlsWarn:   opts.Insecure,
+		health:    opts.Health,
+		dialect:   sqllex.DialectFor(label),
+		app:       tview.NewApplication(),
+		cache:     cache,
+		schema:    &schemaModel{cache: cache},
+		prefs:     loadPrefs(opts.PrefsPath),
+		prefsPath: opts.PrefsPath,
+		keys:      newKeymap(opts.Keys),
+
+		notice:      opts.Notice,
+		connections: opts.Connections,
+		open:        opts.Open,
 	}
 
-	state.setupTheme()
 	root := state.buildLayout()
 
 	state.app.
 		SetRoot(root, true).
 		EnableMouse(true)
 
+	// Keep a handle on the screen for clipboard access.
+	state.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		state.screen = screen
+		return false
+	})
+	state.app.SetAfterDrawFunc(state.highlightQuery)
+
 	// initial focus on tables pane
 	state.app.SetFocus(state.tables)
 
@@ -51,14 +163,20 @@ func Run(ctx context.Context, sdb db.DB, label string) error {
 	state.app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
 		frontName, _ := state.pages.GetFrontPage()
 		focus := state.app.GetFocus()
+		keys := state.keys
+
+		// The completion drop-down only lives while the query input has focus.
+		if state.completion != nil && focus != state.query {
+			state.closeCompletion()
+		}
 
 		// When an overlay is open, ESC/Enter/Ctrl+Q/Ctrl+/ close it.
-		if frontName == "rowDetail" || frontName == "help" {
-			switch {
-			case ev.Key() == tcell.KeyEsc,
-				ev.Key() == tcell.KeyEnter,
-				isCtrlKey(ev, tcell.KeyCtrlQ, 'q'),
-				isCtrlKey(ev, 0, '/'):
+		if frontName == "rowDetail" || frontName == "help" || frontName == "structure" ||
+			frontName == "info" {
+			if isInputField(focus) {
+				return ev // the row detail's column search
+			}
+			if keys.is(ev, "overlay.close") {
 				state.pages.RemovePage(frontName)
 				state.app.SetFocus(state.result)
 				return nil
@@ -66,153 +184,120 @@ func Run(ctx context.Context, sdb db.DB, label string) error {
 			return ev
 		}
 
-		// Vim-style pane navigation (Ctrl+h/j/k/l)
-		switch {
-		case isCtrlKey(ev, tcell.KeyCtrlH, 'h'): // left
-			state.app.SetFocus(state.tables)
-			return nil
-		case isCtrlKey(ev, tcell.KeyCtrlL, 'l'): // right
-			state.app.SetFocus(state.result)
-			return nil
-		case isCtrlKey(ev, tcell.KeyCtrlJ, 'j'): // down
-			state.app.SetFocus(state.query)
-			return nil
-		case isCtrlKey(ev, tcell.KeyCtrlK, 'k'): // up
-			state.app.SetFocus(state.status)
-			return nil
+		// The JSON, blob, array and plan viewers, the column chooser and
+		// the connection form use Enter/keys of their own, so only
+		// ESC/Ctrl+Q close them.
+		if frontName == "jsonView" || frontName == "blobView" || frontName == "arrayView" ||
+			frontName == "planView" || frontName == "columns" || frontName == "connections" {
+			if keys.is(ev, "overlay.close-viewer") && !(isInputField(focus) && ev.Key() == tcell.KeyEsc) {
+				state.pages.RemovePage(frontName)
+				state.app.SetFocus(state.result)
+				return nil
+			}
+			return ev
 		}
 
-		switch {
-		// Quit: Ctrl+Q or Ctrl+C
-		case isCtrlKey(ev, tcell.KeyCtrlQ, 'q') || ev.Key() == tcell.KeyCtrlC:
-			state.app.Stop()
-			return nil
-
-		// Focus query: Ctrl+:
-		// (Ctrl+Shift+; on a US layout – rune ':' with Ctrl)
-		case isCtrlKey(ev, 0, ':') && focus != state.query:
-			state.app.SetFocus(state.query)
-			return nil
+		// The LISTEN/NOTIFY page is full-screen; ESC/Ctrl+N go back.
+		if frontName == "notify" {
+			switch {
+			case keys.is(ev, "global.quit"):
+				state.app.Stop()
+				return nil
+			case ev.Key() == tcell.KeyEsc || keys.is(ev, "global.notify"):
+				state.toggleNotify()
+				return nil
+			}
+			return ev
+		}
 
-		// Reload tables: Ctrl+R
-		case isCtrlKey(ev, tcell.KeyCtrlR, 'r'):
-			_ = state.loadTables()
-			return nil
+		// The command palette keeps keys for its input.
+		if frontName == "palette" {
+			if keys.is(ev, "global.palette") || keys.is(ev, "overlay.close-viewer") {
+				state.closePalette()
+				return nil
+			}
+			return ev
+		}
 
-		// Help: Ctrl+/
-		case isCtrlKey(ev, 0, '/'):
-			state.toggleHelp()
-			return nil
+		// So do saved queries and their parameter form.
+		if frontName == "queries" || frontName == "queryParams" {
+			if keys.is(ev, "overlay.close-viewer") {
+				state.pages.RemovePage(frontName)
+				state.app.SetFocus(state.query)
+				return nil
+			}
+			return ev
+		}
 
-		// Row expand: Enter while focused on results
-		case ev.Key() == tcell.KeyEnter && focus == state.result:
-			state.expandCurrentRow()
+		switch id := keys.action(ev, "global"); {
+		case id == "":
+			// Let widgets handle the key normally.
+			return ev
+		case id == "global.focus-query" && focus == state.query:
+			return ev
+		default:
+			state.runAction(id)
 			return nil
 		}
-
-		// Let widgets handle the key normally.
-		return ev
 	})
 
-	// Initial data load (synchronous, safe before Run).
+	// Initial data load (synchronous, safe before Run). With a persisted
+	// catalog this is instant; the background refresh then updates it.
 	_ = state.loadTables()
+	if w := state.keys.warnings; len(w) > 0 {
+		state.setStatus(yellowTag + "Key bindings:[-] " + tview.Escape(strings.Join(w, "; ")))
+	}
+	if state.notice != "" {
+		state.setStatus(redTag + tview.Escape(state.notice) + "[-]")
+	}
+	cache.SetOnChange(func() {
+		state.app.QueueUpdateDraw(state.onSchemaChange)
+	})
+	cache.Start(ctx)
+	if state.health != nil && state.health.Watched() {
+		state.health.SetOnChange(func() {
+			state.app.QueueUpdateDraw(state.onHealthChange)
+		})
+		state.health.Start(ctx)
+	}
 
+	defer state.stopListening()
 	return state.app.Run()
 }
 
-// Catppuccin Mocha theme.
-// - Borders (all, including tables): #595B72
-// - Titles (section captions): cyan #89DCEB
-// - BINSQL <driver> text keeps its own accent color in buildLayout.
-func (s *uiState) setupTheme() {
-	// Mocha base colors
-	// base:      #1E1E2E (30, 30, 46)
-	// surface0:  #313244 (49, 50, 68)
-	// surface1:  #45475A (69, 71, 90)
-	// text:      #CDD6F4 (205, 214, 244)
-	// subtext0:  #A6ADC8 (166, 173, 200)
-	// overlay2:  #9399B2 (147, 153, 178)
-	// borders:   #595B72 (89, 91, 114)
-	// cyan:      #89DCEB (137, 220, 235)
-
-	tview.Styles.PrimitiveBackgroundColor = tcell.NewRGBColor(30, 30, 46)    // base
-	tview.Styles.ContrastBackgroundColor = tcell.NewRGBColor(49, 50, 68)     // surface0
-	tview.Styles.MoreContrastBackgroundColor = tcell.NewRGBColor(69, 71, 90) // surface1
-
-	// all borders (frames, table borders, separators)
-	tview.Styles.BorderColor = tcell.NewRGBColor(89, 91, 114)
-
-	tview.Styles.PrimaryTextColor = tcell.NewRGBColor(205, 214, 244) // text
-	tview.Styles.SecondaryTextColor = tcell.NewRGBColor(166, 173, 200)
-	tview.Styles.TertiaryTextColor = tcell.NewRGBColor(147, 153, 178)
-
-	// section titles cyan
-	tview.Styles.TitleColor = tcell.NewRGBColor(137, 220, 235)
-
-	// graphics (lines / separators) same as border color
-	tview.Styles.GraphicsColor = tcell.NewRGBColor(89, 91, 114)
-}
-
 func (s *uiState) buildLayout() tview.Primitive {
-	// Connection header: "BINSQL <DRIVER>" with existing accent color.
 	header := tview.NewTextView().
 		SetTextAlign(tview.AlignLeft).
 		SetDynamicColors(true).
-		SetText(fmt.Sprintf("[::b]BINSQL[-]  [#C0A1F0]%s[-]", strings.ToUpper(s.label)))
+		SetText(s.headerText())
 
 	header.SetBorder(true)
 	header.SetBorderPadding(0, 0, 1, 1)
 	header.SetTitle(" Connection ")
+	s.header = header
 
-	// TABLE LIST
-	s.tables = tview.NewList().
-		ShowSecondaryText(false)
-	s.tables.SetBorder(true)
-	s.tables.SetTitle(" Tables ")
-	s.tables.SetDoneFunc(func() {
-		// ESC in list -> focus query.
-		s.app.SetFocus(s.query)
-	})
-	s.tables.SetSelectedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
-		table := mainText
-		if table == "" {
-			return
-		}
-		sql := fmt.Sprintf("SELECT * FROM %s LIMIT 100", table)
-		s.query.SetText(sql)
-		s.runQuery(sql) // synchronous
-	})
+	// TABLE LIST (with its / filter)
+	tablesPane := s.buildTablesPane()
 
 	// HELP BOX under tables, no title.
 	helpBox := tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignLeft).
-		SetText(" Help: Ctrl+?")
+		SetText(" Help: " + tview.Escape(s.keys.label("global.help")))
 	helpBox.SetBorder(true)
 
-	// RESULT TABLE
-	s.result = tview.NewTable().
-		SetBorders(true). // show grid
-		SetFixed(1, 0)
-	s.result.SetBorder(true)
-	s.result.SetTitle(" Results ")
-	s.result.SetSelectable(true, true) // move across cells
+	// RESULT TABLE (with its filter/search prompt)
+	resultPane := s.buildResultPane()
 
 	// QUERY INPUT
-	s.query = tview.NewInputField().
-		SetLabel("> ").
-		SetFieldWidth(0) // grow with window
+	s.query = tview.NewTextArea().
+		SetWrap(false).
+		SetLabel("> ")
 	s.query.SetBorder(true)
-	s.query.SetTitle(" Query (Enter to run) ")
-	s.query.SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyEnter {
-			sql := strings.TrimSpace(s.query.GetText())
-			if sql == "" {
-				return
-			}
-			s.runQuery(sql) // synchronous
-		}
-	})
+	s.query.SetTitle(fmt.Sprintf(" Query (%s to run, %s to complete) ",
+		s.keys.label("query.run"), s.keys.label("query.complete")))
+	s.query.SetInputCapture(s.completionInput)
+	s.query.SetChangedFunc(s.narrowCompletion)
 
 	// STATUS BAR
 	s.status = tview.NewTextView().
@@ -224,11 +309,11 @@ func (s *uiState) buildLayout() tview.Primitive {
 	left := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(header, 3, 0, false).
-		AddItem(s.tables, 0, 1, true).
+		AddItem(tablesPane, 0, 1, true).
 		AddItem(helpBox, 3, 0, false)
 
 	main := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(s.result, 0, 1, false).
+		AddItem(resultPane, 0, 1, false).
 		AddItem(s.query, 3, 0, false).
 		AddItem(s.status, 3, 0, false)
 
@@ -243,203 +328,268 @@ func (s *uiState) buildLayout() tview.Primitive {
 }
 
 func (s *uiState) loadTables() error {
-	s.setStatus("[yellow]Loading tables…[-]")
+	s.setStatus(yellowTag + "Loading tables…[-]")
 
 	tables, err := s.db.ListTables(s.ctx)
 	if err != nil {
-		s.setStatus(fmt.Sprintf("[red]Error loading tables: %v[-]", err))
+		s.setStatus(fmt.Sprintf(redTag+"Error loading tables: %v[-]", err))
 		return err
 	}
 
-	s.tables.Clear()
-	for _, t := range tables {
-		name := strings.TrimSpace(t)
-		if name != "" {
-			s.tables.AddItem(name, "", 0, nil)
-		}
-	}
+	s.fillTables(tables)
 
 	if s.tables.GetItemCount() == 0 {
-		s.setStatus("[gray]No tables found.[-]")
+		s.setStatus(dimTag + "No tables found.[-]")
 	} else {
-		s.tables.SetCurrentItem(0)
-		s.setStatus("[green]Tables loaded. Use arrows + Enter, or type a query below.[-]")
+		s.setStatus(greenTag + "Tables loaded. Use arrows + Enter, or type a query below.[-]")
 	}
 
 	return nil
 }
 
+// refreshSchema reloads the catalog in the background; the cache's
+// change callback repaints the tables pane when it is done.
+func (s *uiState) refreshSchema() {
+	s.setStatus(yellowTag + "Refreshing schema…[-]")
+	go func() { _ = s.cache.Refresh(s.ctx) }()
+}
+
+// onSchemaChange runs (on the UI goroutine) after each cache refresh.
+func (s *uiState) onSchemaChange() {
+	if err := s.cache.Err(); err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Schema refresh failed:[-] %v", err))
+		return
+	}
+	tables := s.cache.Tables()
+	s.fillTables(tables)
+	if s.notice != "" {
+		s.notice = "" // leave it in the status bar this once
+		return
+	}
+	s.setStatus(fmt.Sprintf(greenTag+"Schema loaded[-] "+dimTag+"(%d tables, %s)[-]",
+		len(tables), s.cache.LoadedAt().Format("15:04:05")))
+}
+
+// showStructure lists the columns of the selected table from the cache.
+func (s *uiState) showStructure() {
+	table, ok := s.selectedTable()
+	if !ok {
+		return
+	}
+
+	cols, err := s.cache.DescribeTable(s.ctx, table)
+	if err != nil {
+		s.setStatus(fmt.Sprintf(redTag+"Describe failed:[-] %v", err))
+		return
+	}
+
+	grid := tview.NewTable().
+		SetBorders(false).
+		SetFixed(1, 0)
+	grid.SetCell(0, 0, tview.NewTableCell("column").SetAttributes(tcell.AttrBold).SetSelectable(false))
+	grid.SetCell(0, 1, tview.NewTableCell("type").SetAttributes(tcell.AttrBold).SetSelectable(false))
+	for i, c := range cols {
+		grid.SetCell(i+1, 0, tview.NewTableCell(c.Name))
+		grid.SetCell(i+1, 1, tview.NewTableCell(c.Type).SetTextColor(tview.Styles.SecondaryTextColor))
+	}
+	grid.SetSelectable(true, false)
+	themeSelection(grid)
+
+	frame := tview.NewFrame(grid).
+		SetBorders(0, 0, 1, 1, 1, 1)
+	frame.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Structure: %s (%d columns) ", table, len(cols))).
+		SetTitleAlign(tview.AlignLeft)
+
+	s.pages.AddAndSwitchToPage("structure", centered(frame), true)
+	s.app.SetFocus(grid)
+}
+
 func (s *uiState) runQuery(sql string) {
+	if !s.allowed(sql) {
+		return
+	}
 	start := time.Now()
-	s.setStatus(fmt.Sprintf("[yellow]Running query…[-] [gray]%s[-]", truncateInline(sql, 80)))
+	s.setStatus(fmt.Sprintf(yellowTag+"Running query…[-] "+dimTag+"%s[-]", truncateInline(sql, 80)))
 
 	rows, err := s.db.Query(s.ctx, sql)
 	if err != nil {
-		s.setStatus(fmt.Sprintf("[red]Query error:[-] %v", err))
+		s.setStatus(fmt.Sprintf(redTag+"Query error:[-] %v", err))
 		return
 	}
 
 	elapsed := time.Since(start)
-	s.renderRows(rows)
+	s.renderRows(rows, s.resultTable(sql))
 	s.setStatus(fmt.Sprintf(
-		"[green]Query OK[-] [gray](%d rows, %s)[-]",
+		greenTag+"Query OK[-] "+dimTag+"(%d rows, %s)[-]",
 		len(rows.Data),
 		elapsed.Truncate(time.Millisecond),
 	))
 }
 
-func (s *uiState) renderRows(rows *db.Rows) {
-	s.result.Clear()
+func (s *uiState) renderRows(rows *db.Rows, table string) {
 	s.lastRows = rows
+	s.resetGrid(table)
+	s.applyView()
+}
+
+// drawGrid fills the results table from lastRows in view order, with the
+// visible columns of the current layout.
+func (s *uiState) drawGrid() {
+	s.result.Clear()
+	rows := s.lastRows
+	view := s.grid.view
+	cols := s.grid.cols
 
 	if len(rows.Columns) == 0 {
 		return
 	}
+	if s.grid.transposed {
+		s.drawTransposed()
+		return
+	}
 
-	const maxColWidth = 40
-
-	colCount := len(rows.Columns)
+	colCount := len(cols)
 	colWidths := make([]int, colCount)
+	colLimits := make([]int, colCount)
 
 	// base width from headers
-	for i, col := range rows.Columns {
-		colWidths[i] = runeLen(col.Name)
-		if colWidths[i] > maxColWidth {
-			colWidths[i] = maxColWidth
+	for i, c := range cols {
+		limit, fixed := s.grid.columnWidthLimit(rows.Columns[c].Name)
+		colLimits[i] = limit
+		if fixed {
+			colWidths[i] = limit
+			continue
+		}
+		colWidths[i] = runeLen(rows.Columns[c].Name)
+		if colWidths[i] > limit {
+			colWidths[i] = limit
 		}
 	}
 
 	// refine widths from data (up to some rows)
-	rowLimit := len(rows.Data)
+	rowLimit := len(view)
 	if rowLimit > 200 {
 		rowLimit = 200
 	}
 	for r := 0; r < rowLimit; r++ {
-		row := rows.Data[r]
-		for c := 0; c < colCount && c < len(row); c++ {
-			text := formatValue(row[c])
+		row := rows.Data[view[r]]
+		for i, c := range cols {
+			if c >= len(row) {
+				continue
+			}
+			text, _ := cellfmt.Format(row[c])
 			l := runeLen(text)
-			if l > maxColWidth {
-				l = maxColWidth
+			if l > colLimits[i] {
+				l = colLimits[i]
 			}
-			if l > colWidths[c] {
-				colWidths[c] = l
+			if l > colWidths[i] {
+				colWidths[i] = l
 			}
 		}
 	}
 
 	// header (no special background color – use base theme)
-	for colIdx, col := range rows.Columns {
-		headerText := padRight(col.Name, colWidths[colIdx])
+	for colIdx, c := range cols {
+		name := rows.Columns[c].Name
+		if c == s.grid.sortCol {
+			if s.grid.sortDesc {
+				name += " ▼"
+			} else {
+				name += " ▲"
+			}
+		}
+		headerText := padRight(truncateCell(name, colWidths[colIdx]), colWidths[colIdx])
 		cell := tview.NewTableCell(headerText).
 			SetAlign(tview.AlignLeft).
 			SetSelectable(false).
 			SetAttributes(tcell.AttrBold)
+		// Clicking a header cycles the sort on that column.
+		cell.SetClickedFunc(func() bool {
+			s.cycleSort(c)
+			return true
+		})
 		s.result.SetCell(0, colIdx, cell)
 	}
 
 	// data
-	for rIdx, row := range rows.Data {
-		for cIdx := 0; cIdx < colCount && cIdx < len(row); cIdx++ {
-			text := formatValue(row[cIdx])
-
-			truncated := text
-			if runeLen(truncated) > maxColWidth {
-				truncated = truncateRunes(truncated, maxColWidth-1) + "…"
+	for rIdx, dataIdx := range view {
+		row := rows.Data[dataIdx]
+		for cIdx, c := range cols {
+			if c >= len(row) {
+				continue
 			}
-			display := padRight(truncated, colWidths[cIdx])
+			text, kind := cellfmt.Format(row[c])
+			display := padRight(truncateCell(text, colWidths[cIdx]), colWidths[cIdx])
 
 			align := tview.AlignLeft
-			if looksNumeric(text) {
+			if kind == cellfmt.Plain && looksNumeric(text) {
 				align = tview.AlignRight
 			}
 
 			cell := tview.NewTableCell(display).
 				SetAlign(align).
 				SetSelectable(true)
+			styleCell(cell, kind)
 
-			// zebra striping on a slightly darker Mocha background (mantle: #181825)
+			// zebra striping on a slightly darker background
 			if rIdx%2 == 1 {
-				cell.SetBackgroundColor(tcell.NewRGBColor(24, 24, 37))
+				cell.SetBackgroundColor(zebraColor)
+			}
+			if s.grid.isSearchMatch(rIdx, cIdx) {
+				markSearchMatch(cell)
 			}
 
 			s.result.SetCell(rIdx+1, cIdx, cell)
 		}
 	}
 
+	s.result.SetFixed(1, min(s.grid.layout.Frozen, colCount))
 	s.result.ScrollToBeginning()
 }
 
+// truncateCell shortens text to width runes, ending in "…" when cut.
+func truncateCell(text string, width int) string {
+	if runeLen(text) <= width {
+		return text
+	}
+	if width < 1 {
+		return ""
+	}
+	return truncateRunes(text, width-1) + "…"
+}
+
 func (s *uiState) expandCurrentRow() {
 	if s.lastRows == nil || len(s.lastRows.Data) == 0 {
 		return
 	}
 
-	rowIdx, _ := s.result.GetSelection()
-	if rowIdx <= 0 {
+	rowIdx, colIdx := s.selectedCell()
+	row, ok := s.rowAt(rowIdx)
+	if !ok {
 		return // header
 	}
-	rowIdx-- // adjust for header row
-
-	if rowIdx < 0 || rowIdx >= len(s.lastRows.Data) {
-		return
-	}
-
-	var b strings.Builder
-	b.Grow(256)
 
-	for i, col := range s.lastRows.Columns {
-		b.WriteString(col.Name)
-		b.WriteString(":\n")
-
-		val := ""
-		if i < len(s.lastRows.Data[rowIdx]) {
-			val = formatValue(s.lastRows.Data[rowIdx][i])
+	// JSON cell under the cursor gets the dedicated tree viewer.
+	colIdx = s.dataCol(colIdx)
+	if colIdx >= 0 && colIdx < len(s.lastRows.Columns) && colIdx < len(row) {
+		col := s.lastRows.Columns[colIdx]
+		if isJSONColumn(col, row[colIdx]) {
+			raw, _ := jsonText(row[colIdx])
+			s.showJSONViewer(col, raw)
+			return
 		}
-		if val == "" {
-			val = "NULL"
+		if isPGArrayColumn(col, row[colIdx]) {
+			s.showArrayViewer(col, row[colIdx].(string))
+			return
+		}
+		if b, ok := row[colIdx].([]byte); ok && !blob.IsText(b) {
+			s.showBlobViewer(col, b)
+			return
 		}
-		b.WriteString("  ")
-		b.WriteString(val)
-		b.WriteString("\n\n")
 	}
 
-	text := tview.NewTextView().
-		SetText(b.String()).
-		SetScrollable(true).
-		SetWrap(true).
-		SetWordWrap(true)
-	text.SetDynamicColors(false)
-
-	header := tview.NewTextView().
-		SetTextAlign(tview.AlignCenter).
-		SetText(" Row detail (ESC/Enter/Ctrl+Q/Ctrl+/ to close) ")
-	header.SetDynamicColors(false)
-
-	layout := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(header, 3, 0, false).
-		AddItem(text, 0, 1, true)
-
-	frame := tview.NewFrame(layout).
-		SetBorders(0, 0, 1, 1, 1, 1)
-	frame.SetBorder(true).
-		SetTitle(" Row detail ").
-		SetTitleAlign(tview.AlignLeft)
-
-	// center-ish overlay
-	modal := tview.NewFlex().
-		AddItem(nil, 0, 1, false).
-		AddItem(
-			tview.NewFlex().SetDirection(tview.FlexRow).
-				AddItem(nil, 0, 1, false).
-				AddItem(frame, 0, 3, true).
-				AddItem(nil, 0, 1, false),
-			0, 3, true,
-		).
-		AddItem(nil, 0, 1, false)
-
-	s.pages.AddAndSwitchToPage("rowDetail", modal, true)
-	s.app.SetFocus(text)
+	s.showRowDetail(rowIdx)
 }
 
 func (s *uiState) toggleHelp() {
@@ -452,45 +602,20 @@ func (s *uiState) toggleHelp() {
 	s.showHelp()
 }
 
+// showHelp lists the active key bindings (see keymap.go).
 func (s *uiState) showHelp() {
-	const helpText = `
-[::b]Global[-]
-  Ctrl+Q / Ctrl+C   Quit
-  Ctrl+/            Toggle this help
-
-[::b]Navigation[-]
-  ↑ / ↓             Move in lists/tables
-  Ctrl+h            Focus tables (left)
-  Ctrl+l            Focus results (right)
-  Ctrl+j            Focus query (down)
-  Ctrl+k            Focus status (up)
-
-[::b]Tables pane[-]
-  Enter             SELECT * FROM <table> LIMIT 100
-
-[::b]Results pane[-]
-  Enter             Expand current row
-
-[::b]Query input[-]
-  Enter             Run SQL in the input
-  Ctrl+:            Focus query from anywhere
-
-[::b]Notes[-]
-  Mouse support is enabled (scroll, click).
-  Overlays close with ESC, Enter, Ctrl+Q, or Ctrl+/.`
-
 	txt := tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignLeft).
 		SetScrollable(true).
 		SetWrap(true).
 		SetWordWrap(true)
-	txt.SetText(helpText)
+	txt.SetText(s.keys.helpText())
 
 	header := tview.NewTextView().
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true).
-		SetText("[::b]binsql help (ESC/Enter/Ctrl+Q/Ctrl+/ to close)[-]")
+		SetText("[::b]binsql help[-]  " + s.keys.hint("overlay.close", "close"))
 
 	layout := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(header, 3, 0, false).
@@ -502,19 +627,50 @@ func (s *uiState) showHelp() {
 		SetTitle(" Help ").
 		SetTitleAlign(tview.AlignLeft)
 
-	modal := tview.NewFlex().
+	s.pages.AddAndSwitchToPage("help", centered(frame), true)
+	s.app.SetFocus(txt)
+}
+
+// centered wraps p in spacers so it sits in the middle of the screen.
+func centered(p tview.Primitive) tview.Primitive {
+	return tview.NewFlex().
 		AddItem(nil, 0, 1, false).
 		AddItem(
 			tview.NewFlex().SetDirection(tview.FlexRow).
 				AddItem(nil, 0, 1, false).
-				AddItem(frame, 0, 3, true).
+				AddItem(p, 0, 3, true).
 				AddItem(nil, 0, 1, false),
 			0, 3, true,
 		).
 		AddItem(nil, 0, 1, false)
+}
 
-	s.pages.AddAndSwitchToPage("help", modal, true)
-	s.app.SetFocus(txt)
+// headerText is the connection header: "BINSQL <DRIVER>  <target>
+// <tls>  <health>" with existing accent color, and the read-only flag.
+func (s *uiState) headerText() string {
+	text := fmt.Sprintf("[::b]BINSQL[-]  [%s]%s[-]", accentColor, strings.ToUpper(s.label))
+	if s.target != "" {
+		text += "  " + tview.Escape(s.target)
+	}
+	if s.tls != "" {
+		text += "  [" + planDetailColor + "]" + tview.Escape(s.tls) + "[-]"
+	}
+	if s.tlsWarn {
+		text += "  [" + planScanColor + "::b]CERTIFICATE NOT VERIFIED[-::-]"
+	}
+	if s.readOnly {
+		text += "  [" + accentColor + "::b]READ-ONLY[-::-]"
+	}
+	if s.health != nil && s.health.Watched() {
+		text += "  " + healthText(s.health.Status())
+	}
+	return text
+}
+
+// isInputField reports whether p is a text input, where ESC has its own meaning.
+func isInputField(p tview.Primitive) bool {
+	_, ok := p.(*tview.InputField)
+	return ok
 }
 
 // setStatus updates the status bar text.
@@ -533,18 +689,21 @@ func isCtrlKey(ev *tcell.EventKey, key tcell.Key, ch rune) bool {
 	return ev.Rune() == ch && (ev.Modifiers()&tcell.ModCtrl) != 0
 }
 
+// formatValue is the raw text of a cell (NULL gives ""), used for
+// sorting, filtering and search. Display goes through cellfmt.Format.
 func formatValue(v any) string {
-	if v == nil {
-		return "NULL"
-	}
-	switch val := v.(type) {
-	case []byte:
-		if len(val) > 256 {
-			return fmt.Sprintf("[blob %d bytes]", len(val))
-		}
-		return string(val)
-	default:
-		return fmt.Sprint(val)
+	return cellfmt.Text(v)
+}
+
+// styleCell dims NULLs (in italics), empty and blank strings so they
+// cannot be mistaken for text.
+func styleCell(cell *tview.TableCell, kind cellfmt.Kind) {
+	switch kind {
+	case cellfmt.Null:
+		cell.SetTextColor(tview.Styles.TertiaryTextColor).
+			SetAttributes(tcell.AttrItalic | tcell.AttrDim)
+	case cellfmt.Empty, cellfmt.Blank, cellfmt.Binary:
+		cell.SetTextColor(tview.Styles.TertiaryTextColor)
 	}
 }
 